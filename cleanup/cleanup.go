@@ -46,20 +46,40 @@ func (s *LobbyCleanupService) cleanupLoop() {
 }
 
 func (s *LobbyCleanupService) cleanupInactiveLobbies() {
-	cutoffTime := time.Now().Add(-s.inactiveWindow)
-
-	query := `DELETE FROM lobbies 
-              WHERE (last_played IS NOT NULL AND last_played < $1)
-              OR (last_played IS NULL AND created_at < $1)`
-
-	result, err := s.db.Exec(query, cutoffTime)
+	rowsAffected, err := CloseExpiredLobbies(s.db, s.inactiveWindow)
 	if err != nil {
 		log.Printf("Error cleaning up inactive lobbies: %v", err)
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected > 0 {
 		log.Printf("Cleaned up %d inactive lobbies", rowsAffected)
 	}
 }
+
+const expiredLobbiesQuery = `DELETE FROM lobbies l
+                              WHERE COALESCE(l.last_played, l.created_at) < NOW() - COALESCE(
+                                  (SELECT s.lobbyttl * INTERVAL '1 minute' FROM organizationsettings s WHERE s.orgid = l.orgid),
+                                  $1 * INTERVAL '1 second')`
+
+// CloseExpiredLobbies removes every lobby that has been idle longer than its
+// org's lobbyttl (in minutes), using fallbackWindow for orgs without one.
+// It covers all orgs, so it's only meant for the background sweeper.
+func CloseExpiredLobbies(db *config.Database, fallbackWindow time.Duration) (int64, error) {
+	result, err := db.Exec(expiredLobbiesQuery, int64(fallbackWindow.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// CloseExpiredOrgLobbies is CloseExpiredLobbies for a single org.
+func CloseExpiredOrgLobbies(db *config.Database, orgid string, fallbackWindow time.Duration) (int64, error) {
+	result, err := db.Exec(expiredLobbiesQuery+" AND l.orgid = $2", int64(fallbackWindow.Seconds()), orgid)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,87 @@
+package cleanup
+
+import (
+	"log"
+	"pedersandvoll/foosballapi/config"
+	"pedersandvoll/foosballapi/rating"
+	"time"
+)
+
+type RatingDecayService struct {
+	db            *config.Database
+	checkInterval time.Duration
+	stop          chan struct{}
+}
+
+func NewRatingDecayService(db *config.Database, checkInterval time.Duration) *RatingDecayService {
+	return &RatingDecayService{
+		db:            db,
+		checkInterval: checkInterval,
+		stop:          make(chan struct{}),
+	}
+}
+
+func (s *RatingDecayService) Start() {
+	go s.decayLoop()
+}
+
+func (s *RatingDecayService) Stop() {
+	close(s.stop)
+}
+
+func (s *RatingDecayService) decayLoop() {
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.decayInactiveRatings()
+		case <-s.stop:
+			log.Println("Rating decay service stopping")
+			return
+		}
+	}
+}
+
+func (s *RatingDecayService) decayInactiveRatings() {
+	rowsAffected, err := DecayInactiveRatings(s.db)
+	if err != nil {
+		log.Printf("Error decaying inactive ratings: %v", err)
+		return
+	}
+
+	if rowsAffected > 0 {
+		log.Printf("Decayed %d inactive ratings", rowsAffected)
+	}
+}
+
+// DecayInactiveRatings moves the active season rating of every player who
+// hasn't finished a game in their org's decayafterdays down by decayrate
+// points, at most once a day and never below the default rating. Suspended
+// orgs are left alone.
+func DecayInactiveRatings(db *config.Database) (int64, error) {
+	query := `UPDATE playerratings pr
+              SET rating = GREATEST($1, pr.rating - s.decayrate),
+                  decayed = pr.decayed + pr.rating - GREATEST($1, pr.rating - s.decayrate),
+                  last_decay_at = NOW(),
+                  updated_at = NOW()
+              FROM organizations o
+              JOIN organizationsettings s ON s.orgid = o.orgid
+              WHERE o.orgid = pr.orgid AND pr.seasonid = o.activeseason
+              AND o.deleted_at IS NULL AND o.suspended_at IS NULL
+              AND s.decayrate > 0 AND s.decayafterdays > 0 AND pr.rating > $1
+              AND (pr.last_decay_at IS NULL OR pr.last_decay_at <= NOW() - INTERVAL '1 day')
+              AND NOT EXISTS (
+                  SELECT 1 FROM gameparticipants gp
+                  WHERE gp.userid = pr.userid AND gp.seasonid = pr.seasonid AND gp.status = 'completed'
+                  AND gp.createdat > NOW() - s.decayafterdays * INTERVAL '1 day'
+              )`
+
+	result, err := db.Exec(query, rating.DefaultRating)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
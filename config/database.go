@@ -3,15 +3,17 @@ package config
 import (
 	"database/sql"
 	"fmt"
+	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
 )
 
-type Database struct {
-	*sql.DB
-}
+// MinGameParticipants is one player on each team, the smallest game there is.
+const MinGameParticipants = 2
 
 type Config struct {
 	Host      string
@@ -21,6 +23,35 @@ type Config struct {
 	DBName    string
 	SSLMode   string
 	JWTSecret string
+
+	StandbyHosts  []string
+	FailoverProbe time.Duration
+
+	LobbySweeper     bool
+	RatingDecay      bool
+	LazyLobbyCleanup bool
+	AvailabilityTTL  time.Duration
+	GameCooldown     time.Duration
+	KioskTokenTTL    time.Duration
+	AllowOrgMerge    bool
+
+	SerializableQuotas bool
+	ProvisionalPlayers bool
+	RoleClaim          bool
+	ValidateOrgOwner   bool
+
+	MaxGameParticipants int
+	StreakMilestone     int
+	PasswordHistory     int
+	TimeOfDayMinGames   int
+
+	HSTS           bool
+	HSTSMaxAge     int
+	NoSniff        bool
+	FrameOptions   string
+	ReferrerPolicy string
+	ForceHTTPS     bool
+	TrustProxy     bool
 }
 
 func NewConfig() *Config {
@@ -32,6 +63,35 @@ func NewConfig() *Config {
 		DBName:    getEnv("DB_NAME", "dbname"),
 		SSLMode:   getEnv("DB_SSLMODE", "disable"),
 		JWTSecret: getEnv("JWT_SECRET", "your-default-secret-key"),
+
+		StandbyHosts:  getEnvList("DB_STANDBY_HOSTS"),
+		FailoverProbe: time.Duration(getEnvInt("DB_FAILOVER_PROBE_SECONDS", 15)) * time.Second,
+
+		LobbySweeper:     getEnvBool("LOBBY_SWEEPER", true),
+		RatingDecay:      getEnvBool("RATING_DECAY", false),
+		LazyLobbyCleanup: getEnvBool("LAZY_LOBBY_CLEANUP", true),
+		AvailabilityTTL:  time.Duration(getEnvPositiveInt("AVAILABILITY_TTL_MINUTES", 60)) * time.Minute,
+		GameCooldown:     time.Duration(getEnvInt("GAME_COOLDOWN_SECONDS", 10)) * time.Second,
+		KioskTokenTTL:    time.Duration(getEnvInt("KIOSK_TOKEN_TTL_HOURS", 12)) * time.Hour,
+		AllowOrgMerge:    getEnvBool("ALLOW_ORG_MERGE", false),
+
+		SerializableQuotas: getEnvBool("SERIALIZABLE_QUOTAS", false),
+		ProvisionalPlayers: getEnvBool("PROVISIONAL_PLAYERS", false),
+		RoleClaim:          getEnvBool("ROLE_CLAIM", false),
+		ValidateOrgOwner:   getEnvBool("VALIDATE_ORG_OWNER", true),
+
+		MaxGameParticipants: getEnvIntAtLeast("MAX_GAME_PARTICIPANTS", 8, MinGameParticipants),
+		StreakMilestone:     getEnvInt("STREAK_MILESTONE", 5),
+		PasswordHistory:     getEnvInt("PASSWORD_HISTORY", 0),
+		TimeOfDayMinGames:   getEnvInt("TIME_OF_DAY_MIN_GAMES", 5),
+
+		HSTS:           getEnvBool("SECURITY_HSTS", true),
+		HSTSMaxAge:     getEnvInt("SECURITY_HSTS_MAX_AGE", 31536000),
+		NoSniff:        getEnvBool("SECURITY_NOSNIFF", true),
+		FrameOptions:   getEnv("SECURITY_FRAME_OPTIONS", "DENY"),
+		ReferrerPolicy: getEnv("SECURITY_REFERRER_POLICY", "no-referrer"),
+		ForceHTTPS:     getEnvBool("FORCE_HTTPS", false),
+		TrustProxy:     getEnvBool("TRUST_PROXY", false),
 	}
 }
 
@@ -42,22 +102,99 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-func NewDatabase(config *Config) (*Database, error) {
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvPositiveInt is getEnvInt for settings where zero or less makes no
+// sense; those fall back to the default too.
+func getEnvPositiveInt(key string, defaultValue int) int {
+	return getEnvIntAtLeast(key, defaultValue, 1)
+}
+
+// getEnvIntAtLeast is getEnvInt for settings with a lower bound. Values below
+// minValue fall back to the default, with a warning since the setting was
+// clearly meant to do something.
+func getEnvIntAtLeast(key string, defaultValue int, minValue int) int {
+	value := getEnvInt(key, defaultValue)
+	if value < minValue {
+		log.Printf("%s must be at least %d, using %d", key, minValue, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func openTarget(config *Config, host string, port string) (dbTarget, error) {
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode)
+		host, port, config.User, config.Password, config.DBName, config.SSLMode)
 
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
-		return nil, fmt.Errorf("error opening database: %w", err)
+		return dbTarget{}, fmt.Errorf("error opening database %s:%s: %w", host, port, err)
 	}
 
 	db.SetMaxOpenConns(25)
 	db.SetMaxIdleConns(25)
 	db.SetConnMaxLifetime(5 * time.Minute)
 
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("error connecting to the database: %w", err)
+	return dbTarget{name: host + ":" + port, db: db}, nil
+}
+
+// NewDatabase connects to the primary, or to the first reachable standby
+// from DB_STANDBY_HOSTS when the primary is down. Standbys are given as
+// host or host:port and share the primary's credentials and database name.
+func NewDatabase(config *Config) (*Database, error) {
+	primary, err := openTarget(config, config.Host, config.Port)
+	if err != nil {
+		return nil, err
+	}
+	targets := []dbTarget{primary}
+
+	for _, standby := range config.StandbyHosts {
+		host, port := standby, config.Port
+		if i := strings.LastIndex(standby, ":"); i != -1 {
+			host, port = standby[:i], standby[i+1:]
+		}
+
+		target, err := openTarget(config, host, port)
+		if err != nil {
+			for _, t := range targets {
+				t.db.Close()
+			}
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+
+	d := &Database{targets: targets, probeInterval: config.FailoverProbe, stop: make(chan struct{})}
+	if err := d.connect(); err != nil {
+		d.Close()
+		return nil, err
+	}
+
+	if len(targets) > 1 && d.probeInterval > 0 {
+		go d.probeLoop()
 	}
 
-	return &Database{db}, nil
+	return d, nil
 }
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const pingTimeout = 3 * time.Second
+
+type dbTarget struct {
+	name string
+	db   *sql.DB
+}
+
+// Database is the primary connection pool plus any standbys. Queries go to
+// the current target, which only changes when a probe finds it unreachable
+// or finds the primary reachable again. Besides the regular probes, a query
+// that fails to reach the database starts one straight away.
+type Database struct {
+	mu            sync.RWMutex
+	targets       []dbTarget
+	current       int
+	probeInterval time.Duration
+	probing       atomic.Bool
+	stop          chan struct{}
+	closeOnce     sync.Once
+}
+
+func (d *Database) conn() *sql.DB {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.targets[d.current].db
+}
+
+func (d *Database) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := d.conn().Query(query, args...)
+	d.checkConn(err)
+	return rows, err
+}
+
+func (d *Database) QueryRow(query string, args ...interface{}) *sql.Row {
+	row := d.conn().QueryRow(query, args...)
+	d.checkConn(row.Err())
+	return row
+}
+
+func (d *Database) Exec(query string, args ...interface{}) (sql.Result, error) {
+	result, err := d.conn().Exec(query, args...)
+	d.checkConn(err)
+	return result, err
+}
+
+func (d *Database) Begin() (*sql.Tx, error) {
+	tx, err := d.conn().Begin()
+	d.checkConn(err)
+	return tx, err
+}
+
+func (d *Database) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	tx, err := d.conn().BeginTx(ctx, opts)
+	d.checkConn(err)
+	return tx, err
+}
+
+func (d *Database) Ping() error {
+	return ping(d.conn())
+}
+
+func (d *Database) Close() error {
+	d.closeOnce.Do(func() { close(d.stop) })
+
+	var firstErr error
+	for _, target := range d.targets {
+		if err := target.db.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// IsPrimary reports whether queries currently go to the primary.
+func (d *Database) IsPrimary() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.current == 0
+}
+
+// isConnError reports whether err means the database couldn't be reached,
+// as opposed to the query itself failing.
+func isConnError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// Connection exceptions, and the server shutting down or starting up.
+		switch pqErr.Code {
+		case "57P01", "57P02", "57P03":
+			return true
+		}
+		return pqErr.Code.Class() == "08"
+	}
+	return false
+}
+
+// checkConn probes the targets in the background when err is a connection
+// error, so a dead target is left before the next regular probe. Only one
+// such probe runs at a time.
+func (d *Database) checkConn(err error) {
+	if !isConnError(err) || !d.probing.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer d.probing.Store(false)
+		d.probe()
+	}()
+}
+
+func ping(db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	return db.PingContext(ctx)
+}
+
+func (d *Database) switchTo(i int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.current = i
+}
+
+// connect picks the first reachable target, preferring the primary.
+func (d *Database) connect() error {
+	var lastErr error
+	for i, target := range d.targets {
+		err := ping(target.db)
+		if err == nil {
+			if i != 0 {
+				log.Printf("Primary database unavailable, using standby %s: %v", target.name, lastErr)
+			}
+			d.switchTo(i)
+			return nil
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("error connecting to the database: %w", lastErr)
+}
+
+func (d *Database) probeLoop() {
+	ticker := time.NewTicker(d.probeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			d.probe()
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+func (d *Database) probe() {
+	d.mu.RLock()
+	current := d.current
+	d.mu.RUnlock()
+
+	if current != 0 {
+		if err := ping(d.targets[0].db); err == nil {
+			log.Printf("Primary database %s is back, failing back from %s", d.targets[0].name, d.targets[current].name)
+			d.switchTo(0)
+			return
+		}
+	}
+
+	err := ping(d.targets[current].db)
+	if err == nil {
+		return
+	}
+
+	for i, target := range d.targets {
+		if i == current {
+			continue
+		}
+		if ping(target.db) == nil {
+			log.Printf("Database %s unavailable, failing over to %s: %v", d.targets[current].name, target.name, err)
+			d.switchTo(i)
+			return
+		}
+	}
+	log.Printf("Database %s unavailable and no standby is reachable: %v", d.targets[current].name, err)
+}
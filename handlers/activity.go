@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	DefaultActivityDays = 90
+	MaxActivityDays     = 365
+
+	DefaultActiveMemberDays = 30
+)
+
+var activityWeekdays = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+// activityTimeZone reads the ?tz param that hours and weekdays are bucketed
+// in. It must be an IANA name like Europe/Oslo, and defaults to UTC.
+func activityTimeZone(c *fiber.Ctx) (string, bool) {
+	tz := c.Query("tz", "UTC")
+	if tz == "Local" {
+		return "", false
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return "", false
+	}
+	return tz, true
+}
+
+type ActivityHeatmap struct {
+	Days     int        `json:"days"`
+	Since    time.Time  `json:"since"`
+	TimeZone string     `json:"timezone"`
+	Weekdays []string   `json:"weekdays"`
+	Grid     [7][24]int `json:"grid"`
+	Total    int        `json:"total"`
+}
+
+func (h *Handlers) GetActivityHeatmap(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	days := c.QueryInt("days", DefaultActivityDays)
+	if days < 1 || days > MaxActivityDays {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Days must be between 1 and 365",
+		})
+	}
+
+	tz, ok := activityTimeZone(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unknown time zone",
+		})
+	}
+
+	heatmap := ActivityHeatmap{
+		Days:     days,
+		Since:    time.Now().AddDate(0, 0, -days),
+		TimeZone: tz,
+		Weekdays: activityWeekdays,
+	}
+
+	// ISODOW runs from 1 (monday) to 7 (sunday).
+	query := `SELECT EXTRACT(ISODOW FROM createdat AT TIME ZONE $2)::int, EXTRACT(HOUR FROM createdat AT TIME ZONE $2)::int, COUNT(*)
+              FROM games
+              WHERE orgid = {orgid} AND status = 'completed' AND createdat >= $1
+              GROUP BY 1, 2`
+	rows, err := queryOrg(h.db, activeOrgStr, query, heatmap.Since, tz)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var weekday, hour, games int
+		if err := rows.Scan(&weekday, &hour, &games); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to scan activity",
+			})
+		}
+		heatmap.Grid[weekday-1][hour] = games
+		heatmap.Total += games
+	}
+	if err := rows.Err(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Error iterating activity",
+		})
+	}
+
+	return c.JSON(heatmap)
+}
+
+type ActiveMember struct {
+	UserId      int       `json:"userid"`
+	UserName    string    `json:"username"`
+	LastPlayed  time.Time `json:"lastplayed"`
+	GamesPlayed int       `json:"gamesplayed"`
+}
+
+func (h *Handlers) GetActiveMembers(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	days := c.QueryInt("days", DefaultActiveMemberDays)
+	if days < 1 || days > MaxActivityDays {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Days must be between 1 and 365",
+		})
+	}
+	since := time.Now().AddDate(0, 0, -days)
+
+	page := parsePagination(c)
+
+	query := `SELECT u.userid, u.username, MAX(gp.createdat) AS lastplayed, COUNT(*), COUNT(*) OVER ()
+              FROM users u
+              JOIN gameparticipants gp ON gp.userid = u.userid AND gp.orgid = u.activeorg
+              WHERE u.activeorg = {orgid} AND gp.status = 'completed' AND gp.createdat >= $1
+              GROUP BY u.userid, u.username
+              ORDER BY lastplayed DESC, u.username
+              LIMIT $2 OFFSET $3`
+	rows, err := queryOrg(h.db, activeOrgStr, query, since, page.Limit, page.Offset())
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	defer rows.Close()
+
+	members := []ActiveMember{}
+	total := 0
+	for rows.Next() {
+		var member ActiveMember
+		if err := rows.Scan(&member.UserId, &member.UserName, &member.LastPlayed, &member.GamesPlayed, &total); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to scan member",
+			})
+		}
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Error iterating members",
+		})
+	}
+
+	return respondPaginated(c, members, total, page)
+}
+
+type HourPerformance struct {
+	Hour    int      `json:"hour"`
+	Games   int      `json:"games"`
+	Wins    int      `json:"wins"`
+	WinRate *float64 `json:"winrate"`
+}
+
+type TimeOfDayPerformance struct {
+	UserId   int                 `json:"userid"`
+	Days     int                 `json:"days"`
+	Since    time.Time           `json:"since"`
+	TimeZone string              `json:"timezone"`
+	MinGames int                 `json:"mingames"`
+	Hours    [24]HourPerformance `json:"hours"`
+	BestHour *int                `json:"besthour"`
+}
+
+// Hours with fewer than minGames games get no win rate, so a single lucky
+// game at 7am doesn't show up as the player's best hour.
+func (p *TimeOfDayPerformance) fillWinRates() {
+	for i := range p.Hours {
+		hour := &p.Hours[i]
+		if hour.Games == 0 || hour.Games < p.MinGames {
+			continue
+		}
+		winRate := math.Round(float64(hour.Wins)/float64(hour.Games)*1000) / 10
+		hour.WinRate = &winRate
+		if p.BestHour == nil || winRate > *p.Hours[*p.BestHour].WinRate {
+			best := i
+			p.BestHour = &best
+		}
+	}
+}
+
+func (h *Handlers) GetPerformanceByTimeOfDay(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	days := c.QueryInt("days", DefaultActivityDays)
+	if days < 1 || days > MaxActivityDays {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Days must be between 1 and 365",
+		})
+	}
+
+	userid, err := strconv.Atoi(c.Query("userid", userIdFromToken(c)))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid userid",
+		})
+	}
+
+	members, err := h.countOrgMembers(activeOrgStr, []int{userid})
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if members != 1 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User is not a member of the org",
+		})
+	}
+
+	tz, ok := activityTimeZone(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unknown time zone",
+		})
+	}
+
+	performance := TimeOfDayPerformance{
+		UserId:   userid,
+		Days:     days,
+		Since:    time.Now().AddDate(0, 0, -days),
+		TimeZone: tz,
+		MinGames: h.timeOfDayMinGames,
+	}
+	for hour := range performance.Hours {
+		performance.Hours[hour].Hour = hour
+	}
+
+	query := `SELECT EXTRACT(HOUR FROM createdat AT TIME ZONE $3)::int, COUNT(*), COUNT(*) FILTER (WHERE won)
+              FROM gameparticipants
+              WHERE orgid = {orgid} AND userid = $1 AND status = 'completed' AND createdat >= $2
+              GROUP BY 1`
+	rows, err := queryOrg(h.db, activeOrgStr, query, performance.UserId, performance.Since, tz)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hour, games, wins int
+		if err := rows.Scan(&hour, &games, &wins); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to scan performance",
+			})
+		}
+		performance.Hours[hour].Games = games
+		performance.Hours[hour].Wins = wins
+	}
+	if err := rows.Err(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Error iterating performance",
+		})
+	}
+
+	performance.fillWinRates()
+
+	return c.JSON(performance)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"pedersandvoll/foosballapi/rating"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AvailabilityStatus string
+
+const (
+	AvailabilityFree AvailabilityStatus = "free"
+	AvailabilityBusy AvailabilityStatus = "busy"
+)
+
+type SetAvailabilityBody struct {
+	Status  AvailabilityStatus `json:"status"`
+	Minutes *int               `json:"minutes"`
+}
+
+type AvailablePlayer struct {
+	UserId    int        `json:"userid"`
+	UserName  string     `json:"username"`
+	Rating    int        `json:"rating"`
+	ExpiresAt *time.Time `json:"expiresat"`
+}
+
+func (h *Handlers) SetAvailability(c *fiber.Ctx) error {
+	var body SetAvailabilityBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if body.Status != AvailabilityFree && body.Status != AvailabilityBusy {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Status must be free or busy",
+		})
+	}
+
+	// Availability always expires; leaving minutes out or passing 0 uses the
+	// app's default.
+	ttl := h.availabilityTTL
+	if body.Minutes != nil {
+		if *body.Minutes < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Minutes cannot be negative",
+			})
+		}
+		if *body.Minutes > 0 {
+			ttl = time.Duration(*body.Minutes) * time.Minute
+		}
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	query := `INSERT INTO playeravailability (userid, orgid, status, expires_at) VALUES ($1, $2, $3, $4)
+              ON CONFLICT (userid, orgid) DO UPDATE SET status = $3, expires_at = $4, updated_at = NOW()`
+	_, err := h.db.Exec(query, userIdFromToken(c), activeOrgStr, body.Status, expiresAt)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to set availability",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":   "Availability updated",
+		"status":    body.Status,
+		"expiresat": expiresAt,
+	})
+}
+
+func (h *Handlers) getAvailablePlayers(orgid string, seasonid *int) ([]AvailablePlayer, error) {
+	query := `SELECT u.userid, u.username, COALESCE(pr.rating, $2), pa.expires_at
+              FROM playeravailability pa
+              JOIN users u ON u.userid = pa.userid AND u.activeorg = pa.orgid
+              LEFT JOIN playerratings pr ON pr.userid = u.userid AND pr.seasonid = $1
+              WHERE pa.orgid = {orgid} AND pa.status = 'free' AND (pa.expires_at IS NULL OR pa.expires_at > NOW())
+              ORDER BY u.username`
+	rows, err := queryOrg(h.db, orgid, query, seasonid, rating.DefaultRating)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var players []AvailablePlayer
+	for rows.Next() {
+		var player AvailablePlayer
+		if err := rows.Scan(&player.UserId, &player.UserName, &player.Rating, &player.ExpiresAt); err != nil {
+			return nil, err
+		}
+		players = append(players, player)
+	}
+
+	return players, rows.Err()
+}
+
+func (h *Handlers) GetAvailablePlayers(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	org, err := h.GetOrgDetails(c, activeOrgStr)
+	if err == sql.ErrNoRows {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Organization does not exist",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	players, err := h.getAvailablePlayers(activeOrgStr, org.ActiveSeason)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
+	}
+
+	return c.JSON(players)
+}
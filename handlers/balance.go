@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"pedersandvoll/foosballapi/rating"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const MaxBalancePlayers = 64
+
+type BalanceGroupBody struct {
+	UserIds []int `json:"userids"`
+	Groups  int   `json:"groups"`
+}
+
+type BalancedPlayer struct {
+	UserId int `json:"userid"`
+	Rating int `json:"rating"`
+}
+
+type BalancedGroup struct {
+	Players       []BalancedPlayer `json:"players"`
+	AverageRating float64          `json:"averagerating"`
+}
+
+// snakeDraft sorts players by rating and deals them out 0..k-1, k-1..0, ...
+// Exact balanced partitioning is NP-hard; the snake keeps group averages close
+// enough for picking tables without any search.
+func snakeDraft(players []BalancedPlayer, k int) []BalancedGroup {
+	sort.SliceStable(players, func(i, j int) bool {
+		return players[i].Rating > players[j].Rating
+	})
+
+	groups := make([]BalancedGroup, k)
+	for i, player := range players {
+		round, pos := i/k, i%k
+		if round%2 == 1 {
+			pos = k - 1 - pos
+		}
+		groups[pos].Players = append(groups[pos].Players, player)
+	}
+
+	for i := range groups {
+		ratings := make([]int, 0, len(groups[i].Players))
+		for _, player := range groups[i].Players {
+			ratings = append(ratings, player.Rating)
+		}
+		groups[i].AverageRating = rating.TeamRating(ratings)
+	}
+
+	return groups
+}
+
+func (h *Handlers) BalanceGroup(c *fiber.Ctx) error {
+	var body BalanceGroupBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if body.Groups < 2 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "At least two groups are required",
+		})
+	}
+	if len(body.UserIds) < body.Groups {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Need at least one player per group",
+		})
+	}
+	if len(body.UserIds) > MaxBalancePlayers {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Too many players",
+		})
+	}
+	if hasDuplicates(body.UserIds) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Players must be unique",
+		})
+	}
+
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	org, err := h.GetOrgDetails(c, activeOrgStr)
+	if err == sql.ErrNoRows {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Organization does not exist",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if org.ActiveSeason == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Organization not connected to a season",
+		})
+	}
+
+	members, err := h.countOrgMembers(activeOrgStr, body.UserIds)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if members != len(body.UserIds) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "All players must be members of the org",
+		})
+	}
+
+	ratings, err := getRatings(h.db, *org.ActiveSeason, body.UserIds)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get ratings",
+		})
+	}
+
+	players := make([]BalancedPlayer, 0, len(body.UserIds))
+	for _, userid := range body.UserIds {
+		players = append(players, BalancedPlayer{UserId: userid, Rating: ratings[userid]})
+	}
+
+	return c.JSON(fiber.Map{
+		"groups": snakeDraft(players, body.Groups),
+	})
+}
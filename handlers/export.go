@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+)
+
+type ExportProfile struct {
+	UserId    int     `json:"userid"`
+	UserName  string  `json:"username"`
+	ActiveOrg *string `json:"activeorg"`
+}
+
+type ExportMembership struct {
+	OrgId     int    `json:"orgid"`
+	Name      string `json:"name"`
+	Active    bool   `json:"active"`
+	IsOwner   bool   `json:"isowner"`
+	Suspended bool   `json:"suspended"`
+}
+
+type ExportRating struct {
+	OrgId      int    `json:"orgid"`
+	SeasonId   int    `json:"seasonid"`
+	SeasonName string `json:"seasonname"`
+	Rating     int    `json:"rating"`
+}
+
+type ExportGame struct {
+	GameId     int       `json:"gameid"`
+	OrgId      int       `json:"orgid"`
+	SeasonId   int       `json:"seasonid"`
+	PlayedAt   time.Time `json:"playedat"`
+	Team       int       `json:"team"`
+	Won        bool      `json:"won"`
+	Team1Score int       `json:"team1score"`
+	Team2Score int       `json:"team2score"`
+	Teammates  []string  `json:"teammates"`
+	Opponents  []string  `json:"opponents"`
+}
+
+func (h *Handlers) exportMemberships(userid string) ([]ExportMembership, error) {
+	query := `SELECT o.orgid, o.name, o.orgid = u.activeorg, s.orgowner = u.userid, o.suspended_at IS NOT NULL
+              FROM users u
+              CROSS JOIN organizations o
+              LEFT JOIN organizationsettings s ON s.orgid = o.orgid
+              WHERE u.userid = $1 AND (o.orgid = u.activeorg OR s.orgowner = u.userid)
+              ORDER BY o.orgid`
+	rows, err := h.db.Query(query, userid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memberships []ExportMembership
+	for rows.Next() {
+		var m ExportMembership
+		var active, isOwner sql.NullBool
+		if err := rows.Scan(&m.OrgId, &m.Name, &active, &isOwner, &m.Suspended); err != nil {
+			return nil, err
+		}
+		m.Active = active.Bool
+		m.IsOwner = isOwner.Bool
+		memberships = append(memberships, m)
+	}
+
+	return memberships, rows.Err()
+}
+
+func (h *Handlers) exportRatings(userid string) ([]ExportRating, error) {
+	query := `SELECT pr.orgid, pr.seasonid, s.name, pr.rating
+              FROM playerratings pr
+              JOIN seasons s ON s.seasonid = pr.seasonid
+              WHERE pr.userid = $1
+              ORDER BY pr.seasonid`
+	rows, err := h.db.Query(query, userid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ratings []ExportRating
+	for rows.Next() {
+		var r ExportRating
+		if err := rows.Scan(&r.OrgId, &r.SeasonId, &r.SeasonName, &r.Rating); err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, r)
+	}
+
+	return ratings, rows.Err()
+}
+
+func (h *Handlers) ExportMyData(c *fiber.Ctx) error {
+	userID := userIdFromToken(c)
+
+	var profile ExportProfile
+	err := h.db.QueryRow("SELECT userid, username, activeorg FROM users WHERE userid = $1", userID).
+		Scan(&profile.UserId, &profile.UserName, &profile.ActiveOrg)
+	if err == sql.ErrNoRows {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	memberships, err := h.exportMemberships(userID)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to export memberships",
+		})
+	}
+
+	ratings, err := h.exportRatings(userID)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to export ratings",
+		})
+	}
+
+	// Other players only ever show up by username.
+	queryGames := `SELECT gp.gameid, gp.orgid, gp.seasonid, gp.createdat, gp.team, gp.won, g.team1_score, g.team2_score,
+                       ARRAY(SELECT u.username FROM gameparticipants o JOIN users u ON u.userid = o.userid
+                             WHERE o.gameid = gp.gameid AND o.team = gp.team AND o.userid <> gp.userid),
+                       ARRAY(SELECT u.username FROM gameparticipants o JOIN users u ON u.userid = o.userid
+                             WHERE o.gameid = gp.gameid AND o.team <> gp.team)
+                   FROM gameparticipants gp
+                   JOIN games g ON g.gameid = gp.gameid
+                   WHERE gp.userid = $1
+                   ORDER BY gp.createdat`
+	rows, err := h.db.Query(queryGames, userID)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to export games",
+		})
+	}
+
+	header, err := json.Marshal(fiber.Map{
+		"exportedat":  time.Now(),
+		"profile":     profile,
+		"memberships": memberships,
+		"ratings":     ratings,
+	})
+	if err != nil {
+		rows.Close()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to export data",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="foosball-export-%s.json"`, userID))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer rows.Close()
+
+		w.Write(header[:len(header)-1])
+		w.WriteString(`,"games":[`)
+
+		first := true
+		for rows.Next() {
+			var game ExportGame
+			var teammates, opponents pq.StringArray
+			err := rows.Scan(&game.GameId, &game.OrgId, &game.SeasonId, &game.PlayedAt, &game.Team, &game.Won,
+				&game.Team1Score, &game.Team2Score, &teammates, &opponents)
+			if err != nil {
+				log.Printf("Failed to scan exported game: %v", err)
+				break
+			}
+			game.Teammates = teammates
+			game.Opponents = opponents
+
+			encoded, err := json.Marshal(game)
+			if err != nil {
+				log.Printf("Failed to encode exported game: %v", err)
+				break
+			}
+			if !first {
+				w.WriteByte(',')
+			}
+			first = false
+			w.Write(encoded)
+			w.Flush()
+		}
+		if err := rows.Err(); err != nil {
+			log.Printf("Error iterating exported games: %v", err)
+		}
+
+		w.WriteString("]}")
+		w.Flush()
+	})
+
+	return nil
+}
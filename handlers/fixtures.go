@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Fixture struct {
+	FixtureId   int       `json:"fixtureid"`
+	SeasonId    int       `json:"seasonid"`
+	Team1       []int     `json:"team1"`
+	Team2       []int     `json:"team2"`
+	ScheduledAt time.Time `json:"scheduledat"`
+	CreatedBy   int       `json:"createdby"`
+	GameId      *int      `json:"gameid"`
+}
+
+type CreateFixtureBody struct {
+	Team1       []int     `json:"team1"`
+	Team2       []int     `json:"team2"`
+	ScheduledAt time.Time `json:"scheduledat"`
+}
+
+type FixtureResultBody struct {
+	Team1Score *int `json:"team1score"`
+	Team2Score *int `json:"team2score"`
+}
+
+func (h *Handlers) CreateFixture(c *fiber.Ctx) error {
+	var body CreateFixtureBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if !body.ScheduledAt.After(time.Now()) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Fixtures must be scheduled in the future",
+		})
+	}
+
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	org, status, msg := h.requireUnsuspendedOrg(c, activeOrgStr)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+
+	maxTeamSize, err := h.getMaxTeamSize(activeOrgStr)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if msg := validateTeams(body.Team1, body.Team2, maxTeamSize); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	players := append(append([]int{}, body.Team1...), body.Team2...)
+	members, err := h.countOrgMembers(activeOrgStr, players)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if members != len(players) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "All players must be members of the org",
+		})
+	}
+
+	if org.ActiveSeason == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Organization not connected to a season",
+		})
+	}
+
+	query := `INSERT INTO fixtures (orgid, seasonid, team1_player1, team1_player2, team2_player1, team2_player2, scheduled_at, createdby)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING fixtureid`
+	var fixtureId int
+	err = h.db.QueryRow(query, activeOrgStr, *org.ActiveSeason,
+		body.Team1[0], teamSlot(body.Team1, 1), body.Team2[0], teamSlot(body.Team2, 1),
+		body.ScheduledAt, userIdFromToken(c)).Scan(&fixtureId)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create fixture",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":   "Fixture created successfully",
+		"fixtureid": fixtureId,
+	})
+}
+
+func scanFixture(row interface{ Scan(...interface{}) error }) (Fixture, error) {
+	var fixture Fixture
+	var team1Player1, team2Player1 int
+	var team1Player2, team2Player2 *int
+
+	err := row.Scan(
+		&fixture.FixtureId,
+		&fixture.SeasonId,
+		&team1Player1,
+		&team1Player2,
+		&team2Player1,
+		&team2Player2,
+		&fixture.ScheduledAt,
+		&fixture.CreatedBy,
+		&fixture.GameId,
+	)
+	if err != nil {
+		return Fixture{}, err
+	}
+
+	fixture.Team1 = []int{team1Player1}
+	if team1Player2 != nil {
+		fixture.Team1 = append(fixture.Team1, *team1Player2)
+	}
+	fixture.Team2 = []int{team2Player1}
+	if team2Player2 != nil {
+		fixture.Team2 = append(fixture.Team2, *team2Player2)
+	}
+
+	return fixture, nil
+}
+
+func (h *Handlers) GetFixtures(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	page := parsePagination(c)
+	includePlayed := c.QueryBool("played", false)
+
+	filter := "WHERE orgid = {orgid} AND ($1 OR gameid IS NULL)"
+
+	var total int
+	if err := scanOrgRow(h.db, activeOrgStr, "SELECT COUNT(*) FROM fixtures "+filter, []interface{}{includePlayed}, &total); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
+	}
+
+	query := `SELECT fixtureid, seasonid, team1_player1, team1_player2, team2_player1, team2_player2,
+              scheduled_at, createdby, gameid
+              FROM fixtures ` + filter + ` ORDER BY scheduled_at LIMIT $2 OFFSET $3`
+	rows, err := queryOrg(h.db, activeOrgStr, query, includePlayed, page.Limit, page.Offset())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
+	}
+	defer rows.Close()
+
+	var fixtures []Fixture
+	for rows.Next() {
+		fixture, err := scanFixture(rows)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to scan row"})
+		}
+		fixtures = append(fixtures, fixture)
+	}
+
+	if err = rows.Err(); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Error iterating over rows"})
+	}
+
+	return respondPaginated(c, fixtures, total, page)
+}
+
+func (h *Handlers) RecordFixtureResult(c *fiber.Ctx) error {
+	var body FixtureResultBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := validateScores(body.Team1Score, body.Team2Score); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	fixtureId, err := strconv.Atoi(c.Params("fixtureid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid fixture id",
+		})
+	}
+
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	if _, status, msg := h.requireUnsuspendedOrg(c, activeOrgStr); status != 0 {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+
+	reportedBy, err := strconv.Atoi(userIdFromToken(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Invalid userid format",
+		})
+	}
+
+	var gameId, ratingChange int
+	var milestones []StreakMilestone
+	err = h.runQuotaTx(func(tx *sql.Tx) error {
+		query := `SELECT fixtureid, seasonid, team1_player1, team1_player2, team2_player1, team2_player2,
+                  scheduled_at, createdby, gameid
+                  FROM fixtures WHERE fixtureid = $1 AND orgid = $2 FOR UPDATE`
+		fixture, err := scanFixture(tx.QueryRow(query, fixtureId, activeOrgStr))
+		if err == sql.ErrNoRows {
+			return &requestError{fiber.StatusNotFound, "Fixture not found"}
+		} else if err != nil {
+			return err
+		}
+		if fixture.GameId != nil {
+			return &requestError{fiber.StatusConflict, "Fixture already has a result"}
+		}
+
+		ended, err := h.isSeasonEnded(fixture.SeasonId)
+		if err != nil {
+			return err
+		}
+		if ended {
+			return &requestError{fiber.StatusConflict, "The fixture's season has ended"}
+		}
+
+		players := append(append([]int{}, fixture.Team1...), fixture.Team2...)
+		members, err := h.countOrgMembers(activeOrgStr, players)
+		if err != nil {
+			return err
+		}
+		if members != len(players) {
+			return &requestError{fiber.StatusBadRequest, "All players must still be members of the org"}
+		}
+
+		if err := h.checkGameQuota(tx, activeOrgStr, fixture.SeasonId); err != nil {
+			return err
+		}
+
+		game := completedGame{
+			OrgId:      activeOrgStr,
+			SeasonId:   fixture.SeasonId,
+			Team1:      fixture.Team1,
+			Team2:      fixture.Team2,
+			Team1Score: *body.Team1Score,
+			Team2Score: *body.Team2Score,
+			ReportedBy: &reportedBy,
+			Submitter:  gameSubmitter{UserId: &reportedBy},
+		}
+		gameId, ratingChange, err = insertCompletedGame(tx, game)
+		if err != nil {
+			return err
+		}
+
+		winners, _ := game.result()
+		milestones, err = h.recordStreakMilestones(tx, activeOrgStr, gameId, winners)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("UPDATE fixtures SET gameid = $1 WHERE fixtureid = $2", gameId, fixtureId)
+		return err
+	})
+	if err != nil {
+		return respondTxError(c, err, "Failed to record fixture result")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":      "Fixture result recorded",
+		"gameid":       gameId,
+		"ratingchange": ratingChange,
+		"milestones":   milestones,
+	})
+}
@@ -0,0 +1,810 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+)
+
+type GameStatus string
+
+const (
+	GameStatusPending    GameStatus = "pending"
+	GameStatusInProgress GameStatus = "in_progress"
+	GameStatusCompleted  GameStatus = "completed"
+	GameStatusCanceled   GameStatus = "canceled"
+)
+
+// TeamSize is the most players per team the games table can store.
+const TeamSize = 2
+
+type Game struct {
+	GameId     int        `json:"gameid"`
+	LobbyId    *int       `json:"lobbyid"`
+	SeasonId   int        `json:"seasonid"`
+	Team1      []int      `json:"team1"`
+	Team2      []int      `json:"team2"`
+	Team1Score int        `json:"team1score"`
+	Team2Score int        `json:"team2score"`
+	Status     GameStatus `json:"status"`
+	CreatedAt  time.Time  `json:"createdat"`
+	ReportedBy *int       `json:"reportedby"`
+	Team1Color *string    `json:"team1color"`
+	Team2Color *string    `json:"team2color"`
+	Shutout    bool       `json:"shutout"`
+	Tags       []string   `json:"tags,omitempty"`
+	Spectators []int      `json:"spectators,omitempty"`
+}
+
+type CreateGameBody struct {
+	LobbyId    *int  `json:"lobbyid"`
+	Team1      []int `json:"team1"`
+	Team2      []int `json:"team2"`
+	Team1Score *int  `json:"team1score"`
+	Team2Score *int  `json:"team2score"`
+	Spectators []int `json:"spectators"`
+	ReportedBy *int  `json:"reportedby"`
+
+	Team1New []string `json:"team1new"`
+	Team2New []string `json:"team2new"`
+
+	Team1Color *string `json:"team1color"`
+	Team2Color *string `json:"team2color"`
+
+	Tags []string `json:"tags"`
+
+	PresetId *int `json:"presetid"`
+}
+
+func validateTeamSizes(team1, team2 int, maxTeamSize int) string {
+	if team1 == 0 || team2 == 0 {
+		return "Both teams need at least one player"
+	}
+	if team1 != team2 {
+		return "Teams must be the same size"
+	}
+	if team1 > maxTeamSize {
+		return fmt.Sprintf("Teams can have at most %d players", maxTeamSize)
+	}
+	return ""
+}
+
+func validateTeams(team1, team2 []int, maxTeamSize int) string {
+	if msg := validateTeamSizes(len(team1), len(team2), maxTeamSize); msg != "" {
+		return msg
+	}
+	if hasDuplicates(append(append([]int{}, team1...), team2...)) {
+		return "A player can only be on one team once"
+	}
+	return ""
+}
+
+func validateScores(team1Score, team2Score *int) string {
+	if team1Score == nil || team2Score == nil {
+		return "Both team scores are required"
+	}
+	if *team1Score < 0 || *team2Score < 0 {
+		return "Scores cannot be negative"
+	}
+	if *team1Score == *team2Score {
+		return "A game cannot end in a draw"
+	}
+	return ""
+}
+
+// validateTeamColors checks that the colors a game was played with are the
+// org's two team colors, in either order since teams can switch sides.
+func validateTeamColors(team1Color, team2Color *string, settings OrgSettings) string {
+	if team1Color == nil && team2Color == nil {
+		return ""
+	}
+	if team1Color == nil || team2Color == nil {
+		return "Both team colors are required when recording colors"
+	}
+
+	orgColors := []string{DefaultTeam1Color, DefaultTeam2Color}
+	if settings.Team1Color != nil {
+		orgColors[0] = *settings.Team1Color
+	}
+	if settings.Team2Color != nil {
+		orgColors[1] = *settings.Team2Color
+	}
+
+	switch {
+	case strings.EqualFold(*team1Color, orgColors[0]) && strings.EqualFold(*team2Color, orgColors[1]):
+	case strings.EqualFold(*team1Color, orgColors[1]) && strings.EqualFold(*team2Color, orgColors[0]):
+	default:
+		return fmt.Sprintf("Team colors must be the org's colors %s and %s", orgColors[0], orgColors[1])
+	}
+	return ""
+}
+
+func teamSlot(team []int, i int) *int {
+	if i < len(team) {
+		return &team[i]
+	}
+	return nil
+}
+
+func (h *Handlers) getMaxTeamSize(orgid string) (int, error) {
+	settings, err := h.getOrgSettings(orgid)
+	if err == sql.ErrNoRows || (err == nil && settings.MaxTeamSize == nil) {
+		return TeamSize, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return *settings.MaxTeamSize, nil
+}
+
+// gameCooldownRemaining is keyed on who submitted the game, since the
+// reporter can be set to any member by the client. Each kiosk has its own
+// cooldown.
+func (h *Handlers) gameCooldownRemaining(submitter gameSubmitter) (time.Duration, error) {
+	if h.gameCooldown <= 0 {
+		return 0, nil
+	}
+
+	query, id := "SELECT MAX(createdat) FROM games WHERE submitted_by = $1", submitter.UserId
+	if submitter.KioskId != nil {
+		query, id = "SELECT MAX(createdat) FROM games WHERE submitted_by_kiosk = $1", submitter.KioskId
+	}
+
+	var lastSubmitted *time.Time
+	if err := h.db.QueryRow(query, id).Scan(&lastSubmitted); err != nil {
+		return 0, err
+	}
+	if lastSubmitted == nil {
+		return 0, nil
+	}
+
+	return time.Until(lastSubmitted.Add(h.gameCooldown)), nil
+}
+
+func hasDuplicates(ids []int) bool {
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return true
+		}
+		seen[id] = true
+	}
+	return false
+}
+
+type completedGame struct {
+	OrgId      string
+	SeasonId   int
+	LobbyId    *int
+	Team1      []int
+	Team2      []int
+	Team1Score int
+	Team2Score int
+	ReportedBy *int
+	Submitter  gameSubmitter
+	Team1Color *string
+	Team2Color *string
+	Unrated    bool
+}
+
+// gameSubmitter is whoever sent in a game: a user, or a kiosk that is
+// logged in as nobody.
+type gameSubmitter struct {
+	UserId  *int
+	KioskId *int
+}
+
+func submitterFromToken(c *fiber.Ctx) (gameSubmitter, error) {
+	if kioskid, ok := kioskIdFromToken(c); ok {
+		return gameSubmitter{KioskId: &kioskid}, nil
+	}
+	userid, err := strconv.Atoi(userIdFromToken(c))
+	if err != nil {
+		return gameSubmitter{}, err
+	}
+	return gameSubmitter{UserId: &userid}, nil
+}
+
+func (game completedGame) result() ([]int, []int) {
+	if game.Team2Score > game.Team1Score {
+		return game.Team2, game.Team1
+	}
+	return game.Team1, game.Team2
+}
+
+func insertCompletedGame(tx *sql.Tx, game completedGame) (int, int, error) {
+	queryCreateGame := `INSERT INTO games (orgid, seasonid, lobbyid, team1_player1, team1_player2, team2_player1, team2_player2,
+                        team1_score, team2_score, status, reported_by, submitted_by, submitted_by_kiosk, team1_color, team2_color, last_played)
+                        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, NOW()) RETURNING gameid`
+	var gameId int
+
+	err := tx.QueryRow(queryCreateGame, game.OrgId, game.SeasonId, game.LobbyId,
+		game.Team1[0], teamSlot(game.Team1, 1), game.Team2[0], teamSlot(game.Team2, 1),
+		game.Team1Score, game.Team2Score, GameStatusCompleted, game.ReportedBy, game.Submitter.UserId, game.Submitter.KioskId,
+		game.Team1Color, game.Team2Color).Scan(&gameId)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if game.Unrated {
+		return gameId, 0, nil
+	}
+
+	winners, losers := game.result()
+
+	ratingChange, err := applyGameRatings(tx, game.OrgId, game.SeasonId, winners, losers)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return gameId, ratingChange, nil
+}
+
+func (h *Handlers) checkParticipantCap(body CreateGameBody) string {
+	participants := len(body.Team1) + len(body.Team2) + len(body.Team1New) + len(body.Team2New) + len(body.Spectators)
+	if participants > h.maxGameParticipants {
+		return fmt.Sprintf("A game can have at most %d participants", h.maxGameParticipants)
+	}
+	return ""
+}
+
+func (h *Handlers) CreateGame(c *fiber.Ctx) error {
+	var body CreateGameBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := h.checkParticipantCap(body); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if msg := validateScores(body.Team1Score, body.Team2Score); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	org, status, msg := h.requireUnsuspendedOrg(c, activeOrgStr)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+
+	if body.PresetId != nil {
+		if _, isKiosk := kioskIdFromToken(c); isKiosk {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Presets belong to a user and can't be used from a kiosk",
+			})
+		}
+		if status, msg := h.applyPreset(activeOrgStr, userIdFromToken(c), &body); status != 0 {
+			return c.Status(status).JSON(fiber.Map{"error": msg})
+		}
+	}
+
+	// The preset only fills in the teams, so the cap runs again once it has.
+	if msg := h.checkParticipantCap(body); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	submitter, err := submitterFromToken(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Invalid userid format",
+		})
+	}
+
+	remaining, err := h.gameCooldownRemaining(submitter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if remaining > 0 {
+		seconds := int(math.Ceil(remaining.Seconds()))
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error":      "You just submitted a game, please wait before submitting another",
+			"retryafter": seconds,
+		})
+	}
+
+	maxTeamSize, err := h.getMaxTeamSize(activeOrgStr)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if body.Team1Color != nil || body.Team2Color != nil {
+		settings, err := h.getOrgSettings(activeOrgStr)
+		if err != nil && err != sql.ErrNoRows {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Database error",
+			})
+		}
+		if msg := validateTeamColors(body.Team1Color, body.Team2Color, settings); msg != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": msg,
+			})
+		}
+	}
+
+	// Provisional players are only created inside the game transaction, so a
+	// rejected game doesn't leave any behind.
+	var team1New, team2New []string
+	if len(body.Team1New) > 0 || len(body.Team2New) > 0 {
+		if !h.provisionalPlayers {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Provisional players are disabled",
+			})
+		}
+
+		var msg string
+		team1New, team2New, msg = normalizeProvisionalNames(body.Team1New, body.Team2New)
+		if msg != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": msg,
+			})
+		}
+
+		if err := h.checkProvisionalNames(activeOrgStr, append(append([]string{}, team1New...), team2New...)); err != nil {
+			return respondTxError(c, err, "Database error")
+		}
+	}
+
+	if msg := validateTeamSizes(len(body.Team1)+len(team1New), len(body.Team2)+len(team2New), maxTeamSize); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	players := append(append([]int{}, body.Team1...), body.Team2...)
+	if hasDuplicates(players) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A player can only be on one team once",
+		})
+	}
+	if hasDuplicates(body.Spectators) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Spectators must be unique",
+		})
+	}
+	if hasDuplicates(append(append([]int{}, players...), body.Spectators...)) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Spectators cannot also be players",
+		})
+	}
+
+	if org.ActiveSeason == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Organization not connected to a season",
+		})
+	}
+
+	ended, err := h.isSeasonEnded(*org.ActiveSeason)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if ended {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "The active season has ended, start or select a new season",
+		})
+	}
+
+	// Games from a kiosk have no reporter unless the body names one.
+	reportedBy := submitter.UserId
+	if body.ReportedBy != nil && (reportedBy == nil || *body.ReportedBy != *reportedBy) {
+		reportedBy = body.ReportedBy
+		isMember, err := h.countOrgMembers(activeOrgStr, []int{*reportedBy})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Database error",
+			})
+		}
+		if isMember != 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Reporter must be a member of the org",
+			})
+		}
+	}
+
+	members, err := h.countOrgMembers(activeOrgStr, append(append([]int{}, players...), body.Spectators...))
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if members != len(players)+len(body.Spectators) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "All players and spectators must be members of the org",
+		})
+	}
+
+	var tags []Tag
+	if len(body.Tags) > 0 {
+		tags, err = h.resolveTags(activeOrgStr, body.Tags)
+		if err != nil {
+			log.Printf("Database query error: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Database error",
+			})
+		}
+		if tags == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Tags must be tags of your org",
+			})
+		}
+	}
+	unrated := false
+	for _, tag := range tags {
+		if !tag.Rated {
+			unrated = true
+		}
+	}
+
+	if body.LobbyId != nil {
+		var lobbyId int
+		err := scanOrgRow(h.db, activeOrgStr, "SELECT lobbyid FROM lobbies WHERE lobbyid = $1 AND orgid = {orgid}", []interface{}{*body.LobbyId}, &lobbyId)
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Lobby is not part of your org",
+			})
+		} else if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Database error",
+			})
+		}
+	}
+
+	var gameId, ratingChange int
+	var milestones []StreakMilestone
+	err = h.runQuotaTx(func(tx *sql.Tx) error {
+		if err := h.checkGameQuota(tx, activeOrgStr, *org.ActiveSeason); err != nil {
+			return err
+		}
+
+		team1, team2 := body.Team1, body.Team2
+		if len(team1New) > 0 || len(team2New) > 0 {
+			provisional, err := resolveProvisionalPlayers(tx, activeOrgStr, submitter.UserId, append(append([]string{}, team1New...), team2New...))
+			if err != nil {
+				return err
+			}
+			team1 = append(append([]int{}, team1...), provisional[:len(team1New)]...)
+			team2 = append(append([]int{}, team2...), provisional[len(team1New):]...)
+
+			// A name can match a provisional player that was also entered by userid.
+			if hasDuplicates(append(append(append([]int{}, team1...), team2...), body.Spectators...)) {
+				return &requestError{fiber.StatusBadRequest, "A player can only be in a game once"}
+			}
+		}
+
+		game := completedGame{
+			OrgId:      activeOrgStr,
+			SeasonId:   *org.ActiveSeason,
+			LobbyId:    body.LobbyId,
+			Team1:      team1,
+			Team2:      team2,
+			Team1Score: *body.Team1Score,
+			Team2Score: *body.Team2Score,
+			ReportedBy: reportedBy,
+			Submitter:  submitter,
+			Team1Color: body.Team1Color,
+			Team2Color: body.Team2Color,
+			Unrated:    unrated,
+		}
+
+		var err error
+		gameId, ratingChange, err = insertCompletedGame(tx, game)
+		if err != nil {
+			return err
+		}
+
+		winners, _ := game.result()
+		milestones, err = h.recordStreakMilestones(tx, activeOrgStr, gameId, winners)
+		if err != nil {
+			return err
+		}
+
+		for _, tag := range tags {
+			if _, err := tx.Exec("INSERT INTO gametagassignments (gameid, tagid) VALUES ($1, $2)", gameId, tag.TagId); err != nil {
+				return err
+			}
+		}
+
+		if len(body.Spectators) > 0 {
+			querySpectators := "INSERT INTO gamespectators (gameid, userid) SELECT $1, unnest($2::int[])"
+			if _, err := tx.Exec(querySpectators, gameId, pq.Array(body.Spectators)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return respondTxError(c, err, "Failed to create game")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":      "Game created successfully",
+		"gameid":       gameId,
+		"ratingchange": ratingChange,
+		"milestones":   milestones,
+	})
+}
+
+const gameColumns = `g.gameid, g.lobbyid, g.seasonid, g.team1_player1, g.team1_player2, g.team2_player1, g.team2_player2,
+                      g.team1_score, g.team2_score, g.status, g.createdat, g.reported_by, g.team1_color, g.team2_color, g.shutout AND g.status = 'completed',
+                      ARRAY(SELECT t.name FROM gametagassignments ga JOIN gametags t ON t.tagid = ga.tagid
+                            WHERE ga.gameid = g.gameid ORDER BY t.name)`
+
+func scanGame(row interface{ Scan(...interface{}) error }) (Game, error) {
+	var game Game
+	var team1Player1, team2Player1 int
+	var team1Player2, team2Player2 *int
+	var tags pq.StringArray
+
+	err := row.Scan(
+		&game.GameId,
+		&game.LobbyId,
+		&game.SeasonId,
+		&team1Player1,
+		&team1Player2,
+		&team2Player1,
+		&team2Player2,
+		&game.Team1Score,
+		&game.Team2Score,
+		&game.Status,
+		&game.CreatedAt,
+		&game.ReportedBy,
+		&game.Team1Color,
+		&game.Team2Color,
+		&game.Shutout,
+		&tags,
+	)
+	if err != nil {
+		return Game{}, err
+	}
+
+	game.Team1 = []int{team1Player1}
+	if team1Player2 != nil {
+		game.Team1 = append(game.Team1, *team1Player2)
+	}
+	game.Team2 = []int{team2Player1}
+	if team2Player2 != nil {
+		game.Team2 = append(game.Team2, *team2Player2)
+	}
+	game.Tags = tags
+
+	return game, nil
+}
+
+func (h *Handlers) getGame(gameid int, orgid string) (Game, error) {
+	query, args, err := scopedQuery(orgid, "SELECT "+gameColumns+" FROM games g WHERE g.gameid = $1 AND g.orgid = {orgid}", gameid)
+	if err != nil {
+		return Game{}, err
+	}
+	game, err := scanGame(h.db.QueryRow(query, args...))
+	if err != nil {
+		return Game{}, err
+	}
+
+	var spectators pq.Int64Array
+	querySpectators := "SELECT COALESCE(array_agg(userid ORDER BY userid), '{}') FROM gamespectators WHERE gameid = $1"
+	if err := h.db.QueryRow(querySpectators, game.GameId).Scan(&spectators); err != nil {
+		return Game{}, err
+	}
+	for _, spectator := range spectators {
+		game.Spectators = append(game.Spectators, int(spectator))
+	}
+
+	return game, nil
+}
+
+func (h *Handlers) GetGame(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	gameId, err := strconv.Atoi(c.Params("gameid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid game id",
+		})
+	}
+
+	game, err := h.getGame(gameId, activeOrgStr)
+	if err == sql.ErrNoRows {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Game not found",
+		})
+	} else if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	return c.JSON(game)
+}
+
+func (h *Handlers) GetGames(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	page := parsePagination(c)
+	tag := strings.TrimSpace(c.Query("tag"))
+
+	closeness := c.QueryInt("closeness", 0)
+	if closeness < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Closeness cannot be negative",
+		})
+	}
+
+	// Closeness only applies to completed games, whose scores are final.
+	filter := `WHERE g.orgid = {orgid} AND ($1 = '' OR EXISTS (
+                   SELECT 1 FROM gametagassignments ga JOIN gametags t ON t.tagid = ga.tagid
+                   WHERE ga.gameid = g.gameid AND LOWER(t.name) = LOWER($1)))
+               AND ($2 = 0 OR (g.status = 'completed' AND ABS(g.team1_score - g.team2_score) <= $2))`
+	args := []interface{}{tag, closeness}
+
+	var total int
+	if err := scanOrgRow(h.db, activeOrgStr, "SELECT COUNT(*) FROM games g "+filter, args, &total); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
+	}
+
+	query := "SELECT " + gameColumns + " FROM games g " + filter + " ORDER BY g.createdat DESC, g.gameid DESC LIMIT $3 OFFSET $4"
+	rows, err := queryOrg(h.db, activeOrgStr, query, append(args, page.Limit, page.Offset())...)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
+	}
+	defer rows.Close()
+
+	var games []Game
+	for rows.Next() {
+		game, err := scanGame(rows)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to scan row"})
+		}
+		games = append(games, game)
+	}
+
+	if err = rows.Err(); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Error iterating over rows"})
+	}
+
+	return respondPaginated(c, games, total, page)
+}
+
+const (
+	DefaultClosestGames = 10
+	MaxClosestGames     = 50
+)
+
+func (h *Handlers) GetClosestGames(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	limit := c.QueryInt("limit", DefaultClosestGames)
+	if limit < 1 || limit > MaxClosestGames {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Limit must be between 1 and %d", MaxClosestGames),
+		})
+	}
+
+	org, err := h.GetOrgDetails(c, activeOrgStr)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if org.ActiveSeason == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Organization not connected to a season",
+		})
+	}
+
+	query := "SELECT " + gameColumns + ` FROM games g
+              WHERE g.orgid = {orgid} AND g.seasonid = $1 AND g.status = 'completed'
+              ORDER BY ABS(g.team1_score - g.team2_score), g.createdat DESC, g.gameid DESC
+              LIMIT $2`
+	rows, err := queryOrg(h.db, activeOrgStr, query, *org.ActiveSeason, limit)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	defer rows.Close()
+
+	games := []Game{}
+	for rows.Next() {
+		game, err := scanGame(rows)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to scan game",
+			})
+		}
+		games = append(games, game)
+	}
+	if err := rows.Err(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Error iterating games",
+		})
+	}
+
+	return c.JSON(games)
+}
+
+func (h *Handlers) GetShutouts(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	page := parsePagination(c)
+
+	filter := "WHERE g.orgid = {orgid} AND g.status = 'completed' AND g.shutout"
+
+	var total int
+	if err := scanOrgRow(h.db, activeOrgStr, "SELECT COUNT(*) FROM games g "+filter, nil, &total); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
+	}
+
+	query := "SELECT " + gameColumns + " FROM games g " + filter + " ORDER BY g.createdat DESC, g.gameid DESC LIMIT $1 OFFSET $2"
+	rows, err := queryOrg(h.db, activeOrgStr, query, page.Limit, page.Offset())
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
+	}
+	defer rows.Close()
+
+	var games []Game
+	for rows.Next() {
+		game, err := scanGame(rows)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to scan row"})
+		}
+		games = append(games, game)
+	}
+
+	if err = rows.Err(); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Error iterating over rows"})
+	}
+
+	return respondPaginated(c, games, total, page)
+}
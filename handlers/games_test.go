@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// The handler has no database, so any query before the cap would panic.
+func TestCreateGameParticipantCapFailsFast(t *testing.T) {
+	h := &Handlers{maxGameParticipants: 4}
+	app := testApp(fiber.MethodPost, "/game", h.CreateGame, "1", "1")
+
+	status, body := doJSON(t, app, fiber.MethodPost, "/game", fiber.Map{
+		"team1":      []int{1, 2},
+		"team2":      []int{3, 4},
+		"spectators": []int{5},
+		"team1score": 10,
+		"team2score": 5,
+	})
+	if status != fiber.StatusBadRequest {
+		t.Fatalf("Expected 400 for an oversized roster, got %d", status)
+	}
+	if body["error"] != "A game can have at most 4 participants" {
+		t.Fatalf("Unexpected error: %v", body["error"])
+	}
+}
+
+func TestCreateGameAfterEndSeason(t *testing.T) {
+	db := testDB(t)
+	org := seedOrg(t, db, 2)
+	h := &Handlers{db: db, maxGameParticipants: 8}
+
+	endSeason := testApp(fiber.MethodPost, "/end/season", h.EndSeason, org.Owner, org.OrgId)
+	if status, body := doJSON(t, endSeason, fiber.MethodPost, "/end/season", nil); status != fiber.StatusOK {
+		t.Fatalf("Expected the season to end, got %d: %v", status, body)
+	}
+
+	createGame := testApp(fiber.MethodPost, "/game", h.CreateGame, org.Owner, org.OrgId)
+	status, body := doJSON(t, createGame, fiber.MethodPost, "/game", fiber.Map{
+		"team1":      []int{org.Members[0]},
+		"team2":      []int{org.Members[1]},
+		"team1score": 10,
+		"team2score": 5,
+	})
+	if status != fiber.StatusConflict {
+		t.Fatalf("Expected 409 for a game in an ended season, got %d: %v", status, body)
+	}
+
+	var games int
+	if err := db.QueryRow("SELECT COUNT(*) FROM games WHERE seasonid = $1", org.SeasonId).Scan(&games); err != nil {
+		t.Fatalf("Could not count games: %v", err)
+	}
+	if games != 0 {
+		t.Fatalf("Expected no games in the ended season, found %d", games)
+	}
+}
+
+func TestCreateGameReturnsStreakMilestones(t *testing.T) {
+	db := testDB(t)
+	org := seedOrg(t, db, 2)
+	h := &Handlers{db: db, maxGameParticipants: 8, streakMilestone: 2}
+	app := testApp(fiber.MethodPost, "/game", h.CreateGame, org.Owner, org.OrgId)
+
+	game := fiber.Map{
+		"team1":      []int{org.Members[0]},
+		"team2":      []int{org.Members[1]},
+		"team1score": 10,
+		"team2score": 5,
+	}
+	status, body := doJSON(t, app, fiber.MethodPost, "/game", game)
+	if status != fiber.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %v", status, body)
+	}
+	if milestones, _ := body["milestones"].([]interface{}); len(milestones) != 0 {
+		t.Fatalf("Expected no milestone after one win, got %v", body["milestones"])
+	}
+
+	status, body = doJSON(t, app, fiber.MethodPost, "/game", game)
+	if status != fiber.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %v", status, body)
+	}
+	milestones, _ := body["milestones"].([]interface{})
+	if len(milestones) != 1 {
+		t.Fatalf("Expected one milestone after two wins, got %v", body["milestones"])
+	}
+	if milestone, _ := milestones[0].(map[string]interface{}); milestone["userid"] != float64(org.Members[0]) || milestone["streak"] != float64(2) {
+		t.Fatalf("Unexpected milestone: %v", milestone)
+	}
+
+	var recorded int
+	if err := db.QueryRow("SELECT COUNT(*) FROM streakmilestones WHERE orgid = $1 AND userid = $2", org.OrgId, org.Members[0]).Scan(&recorded); err != nil {
+		t.Fatalf("Could not count milestones: %v", err)
+	}
+	if recorded != 1 {
+		t.Fatalf("Expected the milestone to be stored, found %d", recorded)
+	}
+}
@@ -4,29 +4,141 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"pedersandvoll/foosballapi/cleanup"
 	"pedersandvoll/foosballapi/config"
 	"pedersandvoll/foosballapi/utils"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/lib/pq"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+const DefaultLobbyTTL = 30 * time.Minute
+
 type Handlers struct {
-	db        *config.Database
-	JWTSecret []byte
+	db               *config.Database
+	JWTSecret        []byte
+	lazyLobbyCleanup bool
+	ratingDecay      bool
+	availabilityTTL  time.Duration
+	gameCooldown     time.Duration
+	kioskTokenTTL    time.Duration
+	allowOrgMerge    bool
+
+	serializableQuotas bool
+	provisionalPlayers bool
+	roleClaim          bool
+	validateOrgOwner   bool
+
+	maxGameParticipants int
+	streakMilestone     int
+	passwordHistory     int
+	timeOfDayMinGames   int
+
+	leaderboardImages *imageCache
 }
 
-func NewHandlers(db *config.Database, jwtSecret string) *Handlers {
+func NewHandlers(db *config.Database, cfg *config.Config) *Handlers {
 	return &Handlers{
-		db:        db,
-		JWTSecret: []byte(jwtSecret),
+		db:               db,
+		JWTSecret:        []byte(cfg.JWTSecret),
+		lazyLobbyCleanup: cfg.LazyLobbyCleanup,
+		ratingDecay:      cfg.RatingDecay,
+		availabilityTTL:  cfg.AvailabilityTTL,
+		gameCooldown:     cfg.GameCooldown,
+		kioskTokenTTL:    cfg.KioskTokenTTL,
+		allowOrgMerge:    cfg.AllowOrgMerge,
+
+		serializableQuotas: cfg.SerializableQuotas,
+		provisionalPlayers: cfg.ProvisionalPlayers,
+		roleClaim:          cfg.RoleClaim,
+		validateOrgOwner:   cfg.ValidateOrgOwner,
+
+		maxGameParticipants: cfg.MaxGameParticipants,
+		streakMilestone:     cfg.StreakMilestone,
+		passwordHistory:     cfg.PasswordHistory,
+		timeOfDayMinGames:   cfg.TimeOfDayMinGames,
+
+		leaderboardImages: newImageCache(),
 	}
 }
 
+func activeOrgFromToken(c *fiber.Ctx) (string, bool) {
+	token := c.Locals("user").(*jwt.Token)
+	claims := token.Claims.(jwt.MapClaims)
+	activeOrg, ok := claims["activeorg"].(string)
+	return activeOrg, ok && activeOrg != ""
+}
+
+// userIdFromToken is empty for kiosk tokens, which belong to no user.
+func userIdFromToken(c *fiber.Ctx) string {
+	token := c.Locals("user").(*jwt.Token)
+	claims := token.Claims.(jwt.MapClaims)
+	userid, _ := claims["userid"].(string)
+	return userid
+}
+
+func (h *Handlers) countOrgMembers(orgid string, userids []int) (int, error) {
+	var count int
+
+	query := "SELECT COUNT(*) FROM users WHERE activeorg = {orgid} AND userid = ANY($1)"
+	err := scanOrgRow(h.db, orgid, query, []interface{}{pq.Array(userids)}, &count)
+	return count, err
+}
+
+// isActiveOrgMember is true for registered users whose active org is orgid.
+// Provisional players are left out since they can't log in.
+func (h *Handlers) isActiveOrgMember(orgid string, userid int) (bool, error) {
+	var isMember bool
+
+	query := "SELECT EXISTS (SELECT 1 FROM users WHERE userid = $1 AND activeorg = {orgid} AND NOT provisional)"
+	err := scanOrgRow(h.db, orgid, query, []interface{}{userid}, &isMember)
+	return isMember, err
+}
+
+func (h *Handlers) isOrgOwner(orgid string, userid string) (bool, error) {
+	var isOwner bool
+
+	query := "SELECT EXISTS (SELECT 1 FROM organizationsettings WHERE orgid = $1 AND orgowner = $2)"
+	err := h.db.QueryRow(query, orgid, userid).Scan(&isOwner)
+	return isOwner, err
+}
+
+const (
+	RoleOwner  = "owner"
+	RoleMember = "member"
+)
+
+// addRoleClaim adds the user's role in their active org to the claims. The
+// role is read when the token is minted, so a changed role only shows up in
+// the token after the next refresh.
+func (h *Handlers) addRoleClaim(claims jwt.MapClaims) error {
+	if !h.roleClaim {
+		return nil
+	}
+	orgid, ok := claims["activeorg"].(string)
+	if !ok || orgid == "" {
+		return nil
+	}
+
+	isOwner, err := h.isOrgOwner(orgid, claims["userid"].(string))
+	if err != nil {
+		return err
+	}
+	if isOwner {
+		claims["role"] = RoleOwner
+	} else {
+		claims["role"] = RoleMember
+	}
+	return nil
+}
+
 func (h *Handlers) GenerateToken(c *fiber.Ctx) (string, error) {
 	username := c.Locals("username").(string)
 	userid := c.Locals("userid").(string)
@@ -40,6 +152,9 @@ func (h *Handlers) GenerateToken(c *fiber.Ctx) (string, error) {
 	if userExist.ActiveOrg != nil {
 		claims["activeorg"] = *userExist.ActiveOrg
 	}
+	if err := h.addRoleClaim(claims); err != nil {
+		return "", err
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	t, err := token.SignedString(h.JWTSecret)
@@ -60,9 +175,12 @@ func (h *Handlers) RefreshToken(c *fiber.Ctx) error {
 		"exp":      time.Now().Add(time.Hour * 24).Unix(),
 	}
 
-	if activeOrg, ok := c.Locals("activeOrg").(string); ok && activeOrg != "" {
+	if activeOrg, ok := c.Locals("activeorg").(string); ok && activeOrg != "" {
 		claims["activeorg"] = activeOrg
 	}
+	if err := h.addRoleClaim(claims); err != nil {
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	t, err := token.SignedString(h.JWTSecret)
@@ -74,12 +192,20 @@ func (h *Handlers) RefreshToken(c *fiber.Ctx) error {
 }
 
 type User struct {
-	ID       int    `json:"userid"`
-	UserName string `json:"username"`
+	ID          int    `json:"userid"`
+	UserName    string `json:"username"`
+	Provisional bool   `json:"provisional,omitempty"`
 }
 
 func (h *Handlers) GetUsers(c *fiber.Ctx) error {
-	rows, err := h.db.Query("SELECT userid, username FROM users")
+	page := parseOptionalPagination(c)
+
+	var total int
+	if err := h.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&total); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
+	}
+
+	rows, err := h.db.Query("SELECT userid, username FROM users ORDER BY userid LIMIT $1 OFFSET $2", page.LimitArg(), page.Offset())
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
 	}
@@ -103,7 +229,7 @@ func (h *Handlers) GetUsers(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Error iterating over rows"})
 	}
 
-	return c.JSON(users)
+	return respondPaginated(c, users, total, page)
 }
 
 type UserByName struct {
@@ -118,7 +244,7 @@ func (h *Handlers) getUserByUsername(username string) (UserByName, error) {
 	var userid string
 	var activeorg *string
 
-	query := "SELECT username, password, userid, activeorg FROM users WHERE username=$1;"
+	query := "SELECT username, password, userid, activeorg FROM users WHERE username=$1 AND NOT provisional;"
 	row := h.db.QueryRow(query, username)
 
 	switch err := row.Scan(&username, &password, &userid, &activeorg); err {
@@ -240,6 +366,9 @@ func (h *Handlers) LoginUser(c *fiber.Ctx) error {
 	if userExist.ActiveOrg != nil {
 		claims["activeorg"] = *userExist.ActiveOrg
 	}
+	if err := h.addRoleClaim(claims); err != nil {
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
@@ -304,22 +433,48 @@ func (h *Handlers) CreateOrganization(c *fiber.Ctx) error {
 func (h *Handlers) getOrgBySecret(orgsecret string, c *fiber.Ctx) (string, error) {
 	var orgID string
 
-	query := "SELECT orgid FROM organizations WHERE orgsecret=$1"
+	query := "SELECT orgid FROM organizations WHERE orgsecret=$1 AND deleted_at IS NULL"
 	err := h.db.QueryRow(query, orgsecret).Scan(&orgID)
 	if err != nil {
-		log.Printf("Database query error: %v", err)
-		return "", c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "No org with that secret",
-		})
+		if err != sql.ErrNoRows {
+			log.Printf("Database query error: %v", err)
+		}
+		return "", err
 	}
 
 	return orgID, nil
 }
 
+func compileUsernamePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
 type JoinOrg struct {
 	OrgSecret string `json:"orgsecret"`
 }
 
+// ValidateOrgSecret only says whether the secret belongs to an org. Unknown
+// secrets get the same 200 as known ones so only the body tells them apart.
+func (h *Handlers) ValidateOrgSecret(c *fiber.Ctx) error {
+	var body JoinOrg
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	_, err := h.getOrgBySecret(body.OrgSecret, c)
+	if err != nil && err != sql.ErrNoRows {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get org",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"valid": err == nil,
+	})
+}
+
 func (h *Handlers) JoinOrg(c *fiber.Ctx) error {
 	var body JoinOrg
 	if err := c.BodyParser(&body); err != nil {
@@ -335,17 +490,43 @@ func (h *Handlers) JoinOrg(c *fiber.Ctx) error {
 	}
 
 	orgID, err := h.getOrgBySecret(body.OrgSecret, c)
-	if err != nil {
+	if err == sql.ErrNoRows {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No org with that secret",
+		})
+	} else if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get org",
 		})
 	}
 
+	settings, err := h.getOrgSettings(orgID)
+	if err != nil && err != sql.ErrNoRows {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get org settings",
+		})
+	}
+	if settings.UsernamePattern != nil {
+		pattern, err := compileUsernamePattern(*settings.UsernamePattern)
+		if err != nil {
+			log.Printf("Invalid username pattern for org %s: %v", orgID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Org has an invalid username pattern",
+			})
+		}
+		if !pattern.MatchString(c.Locals("username").(string)) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Username does not match the org's required format: %s", *settings.UsernamePattern),
+			})
+		}
+	}
+
 	token := c.Locals("user").(*jwt.Token)
 	claims := token.Claims.(jwt.MapClaims)
 	userID := claims["userid"].(string)
 
-	query := "UPDATE users SET activeorg = $1 WHERE userid = $2;"
+	query := `UPDATE users SET joined_at = CASE WHEN activeorg IS DISTINCT FROM $1 THEN NOW() ELSE joined_at END,
+              activeorg = $1 WHERE userid = $2;`
 	_, err = h.db.Exec(query, orgID, userID)
 	if err != nil {
 		log.Printf("Database query error: %v", err)
@@ -361,9 +542,15 @@ func (h *Handlers) JoinOrg(c *fiber.Ctx) error {
 		})
 	}
 
+	welcome := DefaultWelcomeMessage
+	if settings.WelcomeMessage != nil {
+		welcome = *settings.WelcomeMessage
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"message":  "Added user to organization",
 		"newtoken": newToken,
+		"welcome":  welcome,
 	})
 }
 
@@ -373,6 +560,53 @@ type OrgSettings struct {
 	MaxGamesPerSeason *int    `json:"maxgamesperseason"`
 	Team1Color        *string `json:"team1color"`
 	Team2Color        *string `json:"team2color"`
+	LobbyTTL          *int    `json:"lobbyttl"`
+	MaxTeamSize       *int    `json:"maxteamsize"`
+	UsernamePattern   *string `json:"usernamepattern"`
+	MinRankedGames    *int    `json:"minrankedgames"`
+	WelcomeMessage    *string `json:"welcomemessage"`
+	DecayRate         *int    `json:"decayrate"`
+	DecayAfterDays    *int    `json:"decayafterdays"`
+}
+
+func (h *Handlers) getOrgSettings(orgid string) (OrgSettings, error) {
+	var settings OrgSettings
+
+	query := `SELECT orgowner, maxlobbies, maxgamesperseason, team1color, team2color, lobbyttl, maxteamsize,
+              usernamepattern, minrankedgames, welcomemessage, decayrate, decayafterdays
+              FROM organizationsettings WHERE orgid = $1`
+	err := h.db.QueryRow(query, orgid).Scan(
+		&settings.OrgOwner,
+		&settings.MaxLobbies,
+		&settings.MaxGamesPerSeason,
+		&settings.Team1Color,
+		&settings.Team2Color,
+		&settings.LobbyTTL,
+		&settings.MaxTeamSize,
+		&settings.UsernamePattern,
+		&settings.MinRankedGames,
+		&settings.WelcomeMessage,
+		&settings.DecayRate,
+		&settings.DecayAfterDays,
+	)
+	return settings, err
+}
+
+const (
+	MaxWelcomeMessageLength = 500
+	DefaultWelcomeMessage   = "Welcome to the organization!"
+)
+
+// sanitizeWelcomeMessage drops control characters other than line breaks, so
+// the message is safe to show as plain text in a client.
+func sanitizeWelcomeMessage(message string) string {
+	message = strings.Map(func(r rune) rune {
+		if r != '\n' && unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, message)
+	return strings.TrimSpace(message)
 }
 
 func (h *Handlers) EditOrgSettings(c *fiber.Ctx) error {
@@ -383,7 +617,10 @@ func (h *Handlers) EditOrgSettings(c *fiber.Ctx) error {
 		})
 	}
 
-	if body.OrgOwner == nil && body.MaxLobbies == nil && body.MaxGamesPerSeason == nil {
+	if body.OrgOwner == nil && body.MaxLobbies == nil && body.MaxGamesPerSeason == nil &&
+		body.Team1Color == nil && body.Team2Color == nil && body.LobbyTTL == nil && body.MaxTeamSize == nil &&
+		body.UsernamePattern == nil && body.MinRankedGames == nil && body.WelcomeMessage == nil &&
+		body.DecayRate == nil && body.DecayAfterDays == nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "At least one option must be passed in",
 		})
@@ -408,6 +645,20 @@ func (h *Handlers) EditOrgSettings(c *fiber.Ctx) error {
 	argCount := 1
 
 	if body.OrgOwner != nil {
+		if h.validateOrgOwner {
+			isMember, err := h.isActiveOrgMember(activeOrgStr, *body.OrgOwner)
+			if err != nil {
+				log.Printf("Database query error: %v", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Database error",
+				})
+			}
+			if !isMember {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Org owner must be an existing member of the organization",
+				})
+			}
+		}
 		query += fmt.Sprintf("orgowner = $%d, ", argCount)
 		args = append(args, *body.OrgOwner)
 		argCount++
@@ -432,6 +683,82 @@ func (h *Handlers) EditOrgSettings(c *fiber.Ctx) error {
 		args = append(args, *body.Team2Color)
 		argCount++
 	}
+	if body.LobbyTTL != nil {
+		if *body.LobbyTTL <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Lobby TTL must be a positive number of minutes",
+			})
+		}
+		query += fmt.Sprintf("lobbyttl = $%d, ", argCount)
+		args = append(args, *body.LobbyTTL)
+		argCount++
+	}
+	if body.MaxTeamSize != nil {
+		if *body.MaxTeamSize < 1 || *body.MaxTeamSize > TeamSize {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Max team size must be between 1 and %d", TeamSize),
+			})
+		}
+		query += fmt.Sprintf("maxteamsize = $%d, ", argCount)
+		args = append(args, *body.MaxTeamSize)
+		argCount++
+	}
+	if body.UsernamePattern != nil {
+		if len(*body.UsernamePattern) > 255 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Username pattern is too long",
+			})
+		}
+		if _, err := compileUsernamePattern(*body.UsernamePattern); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Username pattern is not a valid regular expression",
+			})
+		}
+		query += fmt.Sprintf("usernamepattern = NULLIF($%d, ''), ", argCount)
+		args = append(args, *body.UsernamePattern)
+		argCount++
+	}
+	if body.MinRankedGames != nil {
+		if *body.MinRankedGames < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Minimum ranked games cannot be negative",
+			})
+		}
+		query += fmt.Sprintf("minrankedgames = $%d, ", argCount)
+		args = append(args, *body.MinRankedGames)
+		argCount++
+	}
+	if body.WelcomeMessage != nil {
+		message := sanitizeWelcomeMessage(*body.WelcomeMessage)
+		if len(message) > MaxWelcomeMessageLength {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Welcome message can be at most %d characters", MaxWelcomeMessageLength),
+			})
+		}
+		query += fmt.Sprintf("welcomemessage = NULLIF($%d, ''), ", argCount)
+		args = append(args, message)
+		argCount++
+	}
+	if body.DecayRate != nil {
+		if *body.DecayRate < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Decay rate cannot be negative",
+			})
+		}
+		query += fmt.Sprintf("decayrate = $%d, ", argCount)
+		args = append(args, *body.DecayRate)
+		argCount++
+	}
+	if body.DecayAfterDays != nil {
+		if *body.DecayAfterDays < 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Decay can start after one day at the earliest",
+			})
+		}
+		query += fmt.Sprintf("decayafterdays = $%d, ", argCount)
+		args = append(args, *body.DecayAfterDays)
+		argCount++
+	}
 
 	query = query[:len(query)-2]
 
@@ -450,6 +777,8 @@ func (h *Handlers) EditOrgSettings(c *fiber.Ctx) error {
 	})
 }
 
+const MaxSeasonNameLength = 100
+
 type CreateSeason struct {
 	Name string `json:"name"`
 }
@@ -462,9 +791,15 @@ func (h *Handlers) CreateSeason(c *fiber.Ctx) error {
 		})
 	}
 
+	body.Name = strings.TrimSpace(body.Name)
 	if body.Name == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "At least one option must be passed in",
+			"error": "Season name is required",
+		})
+	}
+	if len(body.Name) > MaxSeasonNameLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Season name can be at most %d characters", MaxSeasonNameLength),
 		})
 	}
 
@@ -489,6 +824,11 @@ func (h *Handlers) CreateSeason(c *fiber.Ctx) error {
 
 	err := h.db.QueryRow(query, body.Name, activeOrgStr).Scan(&name, &seasonid)
 	if err != nil {
+		if strings.Contains(err.Error(), "unique constraint") {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "A season with that name already exists in your org",
+			})
+		}
 		log.Printf("Database query error: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to create season",
@@ -553,11 +893,62 @@ func (h *Handlers) SetActiveSeason(c *fiber.Ctx, seasonId int) error {
 	})
 }
 
+func (h *Handlers) EndSeason(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	isOwner, err := h.isOrgOwner(activeOrgStr, userIdFromToken(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if !isOwner {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the org owner can end a season",
+		})
+	}
+
+	query := `UPDATE seasons SET ended_at = NOW()
+              WHERE seasonid = (SELECT activeseason FROM organizations WHERE orgid = $1) AND ended_at IS NULL
+              RETURNING seasonid`
+	var seasonid int
+	err = h.db.QueryRow(query, activeOrgStr).Scan(&seasonid)
+	if err == sql.ErrNoRows {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "No active season to end",
+		})
+	} else if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to end season",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "Season ended successfully",
+		"seasonid": seasonid,
+	})
+}
+
+func (h *Handlers) isSeasonEnded(seasonid int) (bool, error) {
+	var ended bool
+
+	query := "SELECT ended_at IS NOT NULL FROM seasons WHERE seasonid = $1"
+	err := h.db.QueryRow(query, seasonid).Scan(&ended)
+	return ended, err
+}
+
 type OrgDetails struct {
 	Name         string `json:"name"`
-	OrgSecret    string `json:"orgsecret`
-	OrgOwner     int    `json:"orgowner`
+	OrgSecret    string `json:"orgsecret"`
+	OrgOwner     int    `json:"orgowner"`
 	ActiveSeason *int   `json:"activeseason"`
+	Suspended    bool   `json:"suspended"`
 }
 
 func (h *Handlers) GetOrgDetails(c *fiber.Ctx, orgid string) (OrgDetails, error) {
@@ -565,20 +956,68 @@ func (h *Handlers) GetOrgDetails(c *fiber.Ctx, orgid string) (OrgDetails, error)
 	var orgsecret string
 	var orgowner int
 	var activeseason *int
+	var suspended bool
 
-	query := "SELECT name, orgsecret, orgowner, activeseason FROM organizations WHERE orgid=$1;"
-	row := h.db.QueryRow(query, orgid)
-
-	switch err := row.Scan(&name, &orgowner, &orgsecret, &activeseason); err {
+	query := "SELECT name, orgsecret, orgowner, activeseason, suspended_at IS NOT NULL FROM organizations WHERE orgid = {orgid}"
+	switch err := scanOrgRow(h.db, orgid, query, nil, &name, &orgsecret, &orgowner, &activeseason, &suspended); err {
 	case sql.ErrNoRows:
 		return OrgDetails{}, err
 	case nil:
-		return OrgDetails{Name: name, OrgSecret: orgsecret, OrgOwner: orgowner, ActiveSeason: activeseason}, nil
+		return OrgDetails{Name: name, OrgSecret: orgsecret, OrgOwner: orgowner, ActiveSeason: activeseason, Suspended: suspended}, nil
 	default:
 		return OrgDetails{}, err
 	}
 }
 
+type OrgInfo struct {
+	OrgId        int    `json:"orgid"`
+	Name         string `json:"name"`
+	ActiveSeason *int   `json:"activeseason"`
+	Suspended    bool   `json:"suspended"`
+}
+
+// GetOrg describes the caller's active org. The secret is left out, since
+// anyone holding it can join.
+func (h *Handlers) GetOrg(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	org, err := h.GetOrgDetails(c, activeOrgStr)
+	if err == sql.ErrNoRows {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Organization does not exist",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	orgid, _ := strconv.Atoi(activeOrgStr)
+	return c.JSON(OrgInfo{OrgId: orgid, Name: org.Name, ActiveSeason: org.ActiveSeason, Suspended: org.Suspended})
+}
+
+// GetMyOrgs lists the orgs the caller is a member or owner of, with the
+// suspended ones marked.
+func (h *Handlers) GetMyOrgs(c *fiber.Ctx) error {
+	memberships, err := h.exportMemberships(userIdFromToken(c))
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if memberships == nil {
+		memberships = []ExportMembership{}
+	}
+
+	return c.JSON(memberships)
+}
+
 type LobbyStatus string
 
 const (
@@ -592,8 +1031,37 @@ type LobbyDetails struct {
 	Status    LobbyStatus `json:"status"`
 }
 
+// closeExpiredLobbies only touches the caller's org, so reading one org's
+// lobbies never locks or removes another org's.
+func (h *Handlers) closeExpiredLobbies(orgid string) {
+	if !h.lazyLobbyCleanup {
+		return
+	}
+
+	if _, err := cleanup.CloseExpiredOrgLobbies(h.db, orgid, DefaultLobbyTTL); err != nil {
+		log.Printf("Error closing expired lobbies: %v", err)
+	}
+}
+
 func (h *Handlers) GetLobbies(c *fiber.Ctx) error {
-	rows, err := h.db.Query("SELECT lobbyid, createdby, status FROM lobbies")
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	h.closeExpiredLobbies(activeOrgStr)
+
+	page := parseOptionalPagination(c)
+
+	var total int
+	if err := scanOrgRow(h.db, activeOrgStr, "SELECT COUNT(*) FROM lobbies WHERE orgid = {orgid}", nil, &total); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
+	}
+
+	query := "SELECT lobbyid, createdby, status FROM lobbies WHERE orgid = {orgid} ORDER BY lobbyid LIMIT $1 OFFSET $2"
+	rows, err := queryOrg(h.db, activeOrgStr, query, page.LimitArg(), page.Offset())
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
 	}
@@ -628,7 +1096,23 @@ func (h *Handlers) GetLobbies(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Error iterating over rows"})
 	}
 
-	return c.JSON(lobbies)
+	return respondPaginated(c, lobbies, total, page)
+}
+
+func (h *Handlers) createLobby(orgid string, seasonid int, userid string) (int, error) {
+	h.closeExpiredLobbies(orgid)
+
+	queryCreateLobby := "INSERT INTO lobbies (orgid, seasonid, createdby) VALUES ($1, $2, $3) RETURNING lobbyid"
+	var lobbyId int
+
+	err := h.runQuotaTx(func(tx *sql.Tx) error {
+		if err := h.checkLobbyQuota(tx, orgid); err != nil {
+			return err
+		}
+
+		return tx.QueryRow(queryCreateLobby, orgid, seasonid, userid).Scan(&lobbyId)
+	})
+	return lobbyId, err
 }
 
 func (h *Handlers) CreateLobby(c *fiber.Ctx) error {
@@ -648,15 +1132,9 @@ func (h *Handlers) CreateLobby(c *fiber.Ctx) error {
 		})
 	}
 
-	org, err := h.GetOrgDetails(c, activeOrgStr)
-	if err == sql.ErrNoRows {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Organization does not exist",
-		})
-	} else if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Database error",
-		})
+	org, status, msg := h.requireUnsuspendedOrg(c, activeOrgStr)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
 	}
 	if org.ActiveSeason == nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -664,17 +1142,9 @@ func (h *Handlers) CreateLobby(c *fiber.Ctx) error {
 		})
 	}
 
-	queryCreateLobby := "INSERT INTO lobbies (orgid, seasonid, createdby) VALUES ($1, $2, $3) RETURNING lobbyid"
-	var lobbyId int
-
-	err = h.db.QueryRow(queryCreateLobby, activeOrgStr, org.ActiveSeason,
-
-		userID).Scan(&lobbyId)
+	lobbyId, err := h.createLobby(activeOrgStr, *org.ActiveSeason, userID)
 	if err != nil {
-		log.Printf("Database query error: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create lobby",
-		})
+		return respondTxError(c, err, "Failed to create lobby")
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -723,3 +1193,177 @@ func (h *Handlers) JoinLobby(c *fiber.Ctx) error {
 		"playerid": playerID,
 	})
 }
+
+type StartLobbyGameBody struct {
+	LobbyId int   `json:"lobbyid"`
+	Team1   []int `json:"team1"`
+	Team2   []int `json:"team2"`
+}
+
+func (h *Handlers) StartLobbyGame(c *fiber.Ctx) error {
+	var body StartLobbyGameBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if body.LobbyId == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "LobbyId is required",
+		})
+	}
+
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	if _, status, msg := h.requireUnsuspendedOrg(c, activeOrgStr); status != 0 {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+
+	var seasonid int
+	var status LobbyStatus
+	query := "SELECT seasonid, status FROM lobbies WHERE lobbyid = $1 AND orgid = {orgid}"
+	err := scanOrgRow(h.db, activeOrgStr, query, []interface{}{body.LobbyId}, &seasonid, &status)
+	if err == sql.ErrNoRows {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Lobby not found",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if status == LobbyStatusInGame {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Lobby is already in a game",
+		})
+	}
+
+	maxTeamSize, err := h.getMaxTeamSize(activeOrgStr)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if msg := validateTeams(body.Team1, body.Team2, maxTeamSize); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	players := append(append([]int{}, body.Team1...), body.Team2...)
+
+	var inLobby int
+	queryPlayers := "SELECT COUNT(*) FROM lobbyplayers WHERE lobbyid = $1 AND userid = ANY($2)"
+	if err := h.db.QueryRow(queryPlayers, body.LobbyId, pq.Array(players)).Scan(&inLobby); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if inLobby != len(players) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "All players must be in the lobby",
+		})
+	}
+
+	ended, err := h.isSeasonEnded(seasonid)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if ended {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "The lobby's season has ended, start or select a new season",
+		})
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	queryStatus := "UPDATE lobbies SET status = $1 WHERE lobbyid = $2 AND status = $3"
+	result, err := tx.Exec(queryStatus, LobbyStatusInGame, body.LobbyId, LobbyStatusNotInGame)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start lobby game",
+		})
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Lobby is already in a game",
+		})
+	}
+
+	queryCreateGame := `INSERT INTO games (orgid, seasonid, lobbyid, team1_player1, team1_player2, team2_player1, team2_player2,
+                        status, reported_by, submitted_by)
+                        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9) RETURNING gameid`
+	var gameId int
+	err = tx.QueryRow(queryCreateGame, activeOrgStr, seasonid, body.LobbyId,
+		body.Team1[0], teamSlot(body.Team1, 1), body.Team2[0], teamSlot(body.Team2, 1),
+		GameStatusInProgress, userIdFromToken(c)).Scan(&gameId)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create game",
+		})
+	}
+
+	if err = tx.Commit(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start lobby game",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Lobby game started",
+		"gameid":  gameId,
+	})
+}
+
+func (h *Handlers) GetOrgMembers(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	page := parsePagination(c)
+
+	var total int
+	if err := scanOrgRow(h.db, activeOrgStr, "SELECT COUNT(*) FROM users WHERE activeorg = {orgid}", nil, &total); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
+	}
+
+	query := "SELECT userid, username, provisional FROM users WHERE activeorg = {orgid} ORDER BY username LIMIT $1 OFFSET $2"
+	rows, err := queryOrg(h.db, activeOrgStr, query, page.Limit, page.Offset())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
+	}
+	defer rows.Close()
+
+	var members []User
+	for rows.Next() {
+		var member User
+		if err := rows.Scan(&member.ID, &member.UserName, &member.Provisional); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to scan row"})
+		}
+		members = append(members, member)
+	}
+
+	if err = rows.Err(); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Error iterating over rows"})
+	}
+
+	return respondPaginated(c, members, total, page)
+}
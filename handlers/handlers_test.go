@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"pedersandvoll/foosballapi/config"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lib/pq"
+)
+
+// testDB connects to the database named by TEST_DB_NAME, with the usual DB_*
+// settings for everything else. The database must already be migrated.
+// Tests that need it are skipped when TEST_DB_NAME isn't set.
+func testDB(t *testing.T) *config.Database {
+	t.Helper()
+
+	name := os.Getenv("TEST_DB_NAME")
+	if name == "" {
+		t.Skip("TEST_DB_NAME is not set")
+	}
+
+	cfg := config.NewConfig()
+	cfg.DBName = name
+	cfg.StandbyHosts = nil
+
+	db, err := config.NewDatabase(cfg)
+	if err != nil {
+		t.Fatalf("Could not connect to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// testApp serves the handler as if userid was logged in with activeorg as
+// their active org.
+func testApp(method, path string, handler fiber.Handler, userid, activeorg string) *fiber.App {
+	app := fiber.New()
+	app.Add(method, path, func(c *fiber.Ctx) error {
+		claims := jwt.MapClaims{"userid": userid, "username": "user" + userid, "activeorg": activeorg}
+		c.Locals("user", &jwt.Token{Claims: claims})
+		c.Locals("username", "user"+userid)
+		return c.Next()
+	}, handler)
+	return app
+}
+
+func doRequest(app *fiber.App, method, path string, body interface{}) (int, map[string]interface{}, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req, 10000)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	var decoded map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&decoded)
+	return resp.StatusCode, decoded, nil
+}
+
+func doJSON(t *testing.T, app *fiber.App, method, path string, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+
+	status, decoded, err := doRequest(app, method, path, body)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	return status, decoded
+}
+
+// doJSONList GETs path and decodes the bare array it responds with.
+func doJSONList(t *testing.T, app *fiber.App, path string) []map[string]interface{} {
+	t.Helper()
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil), 10000)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected 200 from GET %s, got %d", path, resp.StatusCode)
+	}
+
+	var decoded []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		t.Fatalf("Could not decode GET %s: %v", path, err)
+	}
+	return decoded
+}
+
+type testOrg struct {
+	OrgId    string
+	SeasonId int
+	Owner    string
+	Members  []int
+}
+
+var testSeq int64
+
+// seedOrg creates an org with an owner, the given number of other members and
+// an active season. Everything is removed again when the test ends.
+func seedOrg(t *testing.T, db *config.Database, members int) testOrg {
+	t.Helper()
+
+	suffix := fmt.Sprintf("%d-%d", time.Now().UnixNano(), atomic.AddInt64(&testSeq, 1))
+	var userids []int
+	t.Cleanup(func() {
+		if _, err := db.Exec("DELETE FROM users WHERE userid = ANY($1)", pq.Array(userids)); err != nil {
+			t.Logf("Could not clean up test users: %v", err)
+		}
+	})
+
+	for i := 0; i <= members; i++ {
+		var userid int
+		err := db.QueryRow("INSERT INTO users (username, password) VALUES ($1, 'x') RETURNING userid",
+			fmt.Sprintf("test-%s-%d", suffix, i)).Scan(&userid)
+		if err != nil {
+			t.Fatalf("Could not create test user: %v", err)
+		}
+		userids = append(userids, userid)
+	}
+
+	var org testOrg
+	org.Owner = fmt.Sprint(userids[0])
+	org.Members = userids[1:]
+
+	var orgid int
+	if err := db.QueryRow("INSERT INTO organizations (name, orgowner) VALUES ($1, $2) RETURNING orgid",
+		"test-"+suffix, userids[0]).Scan(&orgid); err != nil {
+		t.Fatalf("Could not create test org: %v", err)
+	}
+	org.OrgId = fmt.Sprint(orgid)
+
+	if _, err := db.Exec("INSERT INTO organizationsettings (orgid, orgowner) VALUES ($1, $2)", orgid, userids[0]); err != nil {
+		t.Fatalf("Could not create test org settings: %v", err)
+	}
+	if err := db.QueryRow("INSERT INTO seasons (name, orgid) VALUES ($1, $2) RETURNING seasonid",
+		"test-"+suffix, orgid).Scan(&org.SeasonId); err != nil {
+		t.Fatalf("Could not create test season: %v", err)
+	}
+	if _, err := db.Exec("UPDATE organizations SET activeseason = $1 WHERE orgid = $2", org.SeasonId, orgid); err != nil {
+		t.Fatalf("Could not set active season: %v", err)
+	}
+	if _, err := db.Exec("UPDATE users SET activeorg = $1 WHERE userid = ANY($2)", orgid, pq.Array(userids)); err != nil {
+		t.Fatalf("Could not add test users to org: %v", err)
+	}
+
+	return org
+}
+
+func TestGetLobbiesClosesExpiredLobbies(t *testing.T) {
+	db := testDB(t)
+	org := seedOrg(t, db, 0)
+	other := seedOrg(t, db, 0)
+
+	if _, err := db.Exec("UPDATE organizationsettings SET lobbyttl = 30 WHERE orgid = ANY($1)", pq.Array([]string{org.OrgId, other.OrgId})); err != nil {
+		t.Fatalf("Could not set lobby ttl: %v", err)
+	}
+
+	var expired, fresh, otherExpired int
+	queryLobby := `INSERT INTO lobbies (orgid, seasonid, createdby, created_at)
+                   VALUES ($1, $2, $3, NOW() - $4 * INTERVAL '1 minute') RETURNING lobbyid`
+	if err := db.QueryRow(queryLobby, org.OrgId, org.SeasonId, org.Owner, 31).Scan(&expired); err != nil {
+		t.Fatalf("Could not create expired lobby: %v", err)
+	}
+	if err := db.QueryRow(queryLobby, org.OrgId, org.SeasonId, org.Owner, 5).Scan(&fresh); err != nil {
+		t.Fatalf("Could not create fresh lobby: %v", err)
+	}
+	if err := db.QueryRow(queryLobby, other.OrgId, other.SeasonId, other.Owner, 31).Scan(&otherExpired); err != nil {
+		t.Fatalf("Could not create other org's lobby: %v", err)
+	}
+
+	h := &Handlers{db: db, lazyLobbyCleanup: true}
+	app := testApp(fiber.MethodGet, "/lobbies", h.GetLobbies, org.Owner, org.OrgId)
+
+	status, body := doJSON(t, app, fiber.MethodGet, "/lobbies?envelope=true&limit=100", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", status, body)
+	}
+
+	listed := map[string]bool{}
+	data, _ := body["data"].([]interface{})
+	for _, item := range data {
+		if lobby, ok := item.(map[string]interface{}); ok {
+			listed[fmt.Sprint(lobby["lobbyid"])] = true
+		}
+	}
+	if listed[fmt.Sprint(expired)] {
+		t.Fatalf("Lobby idle for longer than the org's ttl is still listed")
+	}
+	if !listed[fmt.Sprint(fresh)] {
+		t.Fatalf("Lobby inside the org's ttl is missing")
+	}
+
+	var remaining int
+	if err := db.QueryRow("SELECT COUNT(*) FROM lobbies WHERE lobbyid = $1", expired).Scan(&remaining); err != nil {
+		t.Fatalf("Could not look up expired lobby: %v", err)
+	}
+	if remaining != 0 {
+		t.Fatalf("Expired lobby was not closed")
+	}
+
+	// Listing lobbies only cleans up the caller's own org.
+	if err := db.QueryRow("SELECT COUNT(*) FROM lobbies WHERE lobbyid = $1", otherExpired).Scan(&remaining); err != nil {
+		t.Fatalf("Could not look up other org's lobby: %v", err)
+	}
+	if remaining != 1 {
+		t.Fatalf("Listing lobbies closed another org's lobby")
+	}
+}
+
+func TestStatsOnlyCoverOrgMembers(t *testing.T) {
+	db := testDB(t)
+	org := seedOrg(t, db, 0)
+	other := seedOrg(t, db, 0)
+
+	h := &Handlers{db: db}
+	for _, path := range []string{"/stats/player", "/stats/timeofday"} {
+		handler := h.GetPlayerStats
+		if path == "/stats/timeofday" {
+			handler = h.GetPerformanceByTimeOfDay
+		}
+		app := testApp(fiber.MethodGet, path, handler, org.Owner, org.OrgId)
+
+		if status, body := doJSON(t, app, fiber.MethodGet, path+"?userid="+org.Owner, nil); status != fiber.StatusOK {
+			t.Fatalf("%s: expected 200 for a member, got %d: %v", path, status, body)
+		}
+		if status, body := doJSON(t, app, fiber.MethodGet, path+"?userid="+other.Owner, nil); status != fiber.StatusNotFound {
+			t.Fatalf("%s: expected 404 for another org's user, got %d: %v", path, status, body)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handlers) GetHealth(c *fiber.Ctx) error {
+	role := "replica"
+	if h.db.IsPrimary() {
+		role = "primary"
+	}
+	database := fiber.Map{
+		"role": role,
+	}
+
+	if err := h.db.Ping(); err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":   "unavailable",
+			"database": database,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":   "ok",
+		"database": database,
+	})
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"pedersandvoll/foosballapi/middleware"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type RevokeKioskTokenBody struct {
+	KioskId int `json:"kioskid"`
+}
+
+func (h *Handlers) IsKioskTokenActive(kioskid string) (bool, error) {
+	var active bool
+
+	query := "SELECT EXISTS (SELECT 1 FROM kiosktokens WHERE kioskid = $1 AND revoked_at IS NULL AND expires_at > NOW())"
+	err := h.db.QueryRow(query, kioskid).Scan(&active)
+	return active, err
+}
+
+// kioskIdFromToken returns the kiosk behind a kiosk token. Kiosk tokens
+// don't carry a userid, so anything they record is put on the kiosk instead.
+func kioskIdFromToken(c *fiber.Ctx) (int, bool) {
+	if c.Locals("tokentype") != middleware.TokenTypeKiosk {
+		return 0, false
+	}
+	token := c.Locals("user").(*jwt.Token)
+	claims := token.Claims.(jwt.MapClaims)
+	kioskid, _ := claims["kioskid"].(string)
+	id, err := strconv.Atoi(kioskid)
+	return id, err == nil
+}
+
+func (h *Handlers) requireOrgOwner(c *fiber.Ctx) (string, int, string) {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return "", fiber.StatusInternalServerError, "User not part of any org"
+	}
+
+	isOwner, err := h.isOrgOwner(activeOrgStr, userIdFromToken(c))
+	if err != nil {
+		return "", fiber.StatusInternalServerError, "Database error"
+	}
+	if !isOwner {
+		return "", fiber.StatusForbidden, "Only the org owner can do this"
+	}
+
+	return activeOrgStr, 0, ""
+}
+
+func (h *Handlers) CreateKioskToken(c *fiber.Ctx) error {
+	activeOrgStr, status, msg := h.requireOrgOwner(c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+
+	userID := userIdFromToken(c)
+	expiresAt := time.Now().Add(h.kioskTokenTTL)
+
+	query := "INSERT INTO kiosktokens (orgid, createdby, expires_at) VALUES ($1, $2, $3) RETURNING kioskid"
+	var kioskId int
+	if err := h.db.QueryRow(query, activeOrgStr, userID, expiresAt).Scan(&kioskId); err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create kiosk token",
+		})
+	}
+
+	claims := jwt.MapClaims{
+		"username":  "kiosk",
+		"activeorg": activeOrgStr,
+		"type":      middleware.TokenTypeKiosk,
+		"kioskid":   strconv.Itoa(kioskId),
+		"exp":       expiresAt.Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	t, err := token.SignedString(h.JWTSecret)
+	if err != nil {
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":   "Kiosk token created",
+		"kioskid":   kioskId,
+		"token":     t,
+		"expiresat": expiresAt,
+	})
+}
+
+func (h *Handlers) RevokeKioskToken(c *fiber.Ctx) error {
+	var body RevokeKioskTokenBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	activeOrgStr, status, msg := h.requireOrgOwner(c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+
+	query := `UPDATE kiosktokens SET revoked_at = NOW()
+              WHERE kioskid = $1 AND orgid = $2 AND revoked_at IS NULL
+              RETURNING kioskid`
+	var kioskId int
+	err := h.db.QueryRow(query, body.KioskId, activeOrgStr).Scan(&kioskId)
+	if err == sql.ErrNoRows {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Kiosk token not found",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to revoke kiosk token",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Kiosk token revoked",
+		"kioskid": kioskId,
+	})
+}
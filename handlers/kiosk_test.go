@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"pedersandvoll/foosballapi/middleware"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// kioskApp serves the handler as a kiosk token for activeorg would.
+func kioskApp(method, path string, handler fiber.Handler, kioskid int, activeorg string) *fiber.App {
+	app := fiber.New()
+	app.Add(method, path, func(c *fiber.Ctx) error {
+		claims := jwt.MapClaims{"username": "kiosk", "activeorg": activeorg, "type": middleware.TokenTypeKiosk, "kioskid": fmt.Sprint(kioskid)}
+		c.Locals("user", &jwt.Token{Claims: claims})
+		c.Locals("tokentype", middleware.TokenTypeKiosk)
+		c.Locals("kioskid", fmt.Sprint(kioskid))
+		return c.Next()
+	}, handler)
+	return app
+}
+
+func TestCreateGameFromKiosk(t *testing.T) {
+	db := testDB(t)
+	org := seedOrg(t, db, 2)
+
+	var kioskid int
+	queryKiosk := "INSERT INTO kiosktokens (orgid, createdby, expires_at) VALUES ($1, $2, $3) RETURNING kioskid"
+	if err := db.QueryRow(queryKiosk, org.OrgId, org.Owner, time.Now().Add(time.Hour)).Scan(&kioskid); err != nil {
+		t.Fatalf("Could not create kiosk token: %v", err)
+	}
+
+	h := &Handlers{db: db, maxGameParticipants: 8, gameCooldown: time.Minute}
+	body := fiber.Map{
+		"team1":      []int{org.Members[0]},
+		"team2":      []int{org.Members[1]},
+		"team1score": 10,
+		"team2score": 5,
+	}
+
+	kiosk := kioskApp(fiber.MethodPost, "/game", h.CreateGame, kioskid, org.OrgId)
+	status, resp := doJSON(t, kiosk, fiber.MethodPost, "/game", body)
+	if status != fiber.StatusCreated {
+		t.Fatalf("Expected the kiosk to create a game, got %d: %v", status, resp)
+	}
+
+	gameid, _ := resp["gameid"].(float64)
+	var submittedBy sql.NullInt64
+	var submittedByKiosk int
+	query := "SELECT submitted_by, submitted_by_kiosk FROM games WHERE gameid = $1"
+	if err := db.QueryRow(query, int(gameid)).Scan(&submittedBy, &submittedByKiosk); err != nil {
+		t.Fatalf("Could not look up game: %v", err)
+	}
+	if submittedBy.Valid || submittedByKiosk != kioskid {
+		t.Fatalf("Expected the game to be submitted by kiosk %d only, got user %v and kiosk %d", kioskid, submittedBy, submittedByKiosk)
+	}
+
+	// The kiosk's cooldown is its own, so the owner can still submit.
+	if status, resp := doJSON(t, kiosk, fiber.MethodPost, "/game", body); status != fiber.StatusTooManyRequests {
+		t.Fatalf("Expected the kiosk to be in its cooldown, got %d: %v", status, resp)
+	}
+	owner := testApp(fiber.MethodPost, "/game", h.CreateGame, org.Owner, org.OrgId)
+	if status, resp := doJSON(t, owner, fiber.MethodPost, "/game", body); status != fiber.StatusCreated {
+		t.Fatalf("Expected the owner to be outside the kiosk's cooldown, got %d: %v", status, resp)
+	}
+}
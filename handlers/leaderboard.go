@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"pedersandvoll/foosballapi/rating"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+)
+
+type LeaderboardEntry struct {
+	Rank        int    `json:"rank"`
+	UserId      int    `json:"userid"`
+	UserName    string `json:"username"`
+	Rating      int    `json:"rating"`
+	GamesPlayed int    `json:"gamesplayed"`
+}
+
+// rankedPlayersQuery ranks the members of a season ($1) that have played at
+// least $2 completed games.
+const rankedPlayersQuery = `WITH eligible AS (
+                                SELECT pr.userid, u.username, pr.rating, COUNT(gp.gameid) AS games
+                                FROM playerratings pr
+                                JOIN users u ON u.userid = pr.userid AND u.activeorg = pr.orgid
+                                LEFT JOIN gameparticipants gp
+                                    ON gp.userid = pr.userid AND gp.seasonid = pr.seasonid AND gp.status = 'completed'
+                                WHERE pr.seasonid = $1
+                                GROUP BY pr.userid, u.username, pr.rating
+                                HAVING COUNT(gp.gameid) >= $2
+                            ), ranked AS (
+                                SELECT userid, username, rating, games,
+                                    RANK() OVER (ORDER BY rating DESC) AS rank,
+                                    COUNT(*) OVER () AS total
+                                FROM eligible
+                            )`
+
+func (h *Handlers) getMinRankedGames(orgid string) (int, error) {
+	settings, err := h.getOrgSettings(orgid)
+	if err == sql.ErrNoRows || (err == nil && settings.MinRankedGames == nil) {
+		return DefaultMinRankedGames, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return *settings.MinRankedGames, nil
+}
+
+type leaderboardScope struct {
+	OrgId    string
+	SeasonId int
+	MinGames int
+}
+
+func (h *Handlers) activeLeaderboardScope(c *fiber.Ctx) (leaderboardScope, int, string) {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return leaderboardScope{}, fiber.StatusInternalServerError, "User not part of any org"
+	}
+
+	org, err := h.GetOrgDetails(c, activeOrgStr)
+	if err != nil {
+		return leaderboardScope{}, fiber.StatusInternalServerError, "Database error"
+	}
+	if org.ActiveSeason == nil {
+		return leaderboardScope{}, fiber.StatusInternalServerError, "Organization not connected to a season"
+	}
+
+	minGames, err := h.getMinRankedGames(activeOrgStr)
+	if err != nil {
+		return leaderboardScope{}, fiber.StatusInternalServerError, "Database error"
+	}
+
+	return leaderboardScope{OrgId: activeOrgStr, SeasonId: *org.ActiveSeason, MinGames: minGames}, 0, ""
+}
+
+func (h *Handlers) getLeaderboard(seasonid int, minGames int, page Pagination) ([]LeaderboardEntry, int, error) {
+	query := rankedPlayersQuery + `
+              SELECT rank, userid, username, rating, games, total FROM ranked
+              ORDER BY rank, username LIMIT $3 OFFSET $4`
+	rows, err := h.db.Query(query, seasonid, minGames, page.Limit, page.Offset())
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []LeaderboardEntry
+	var total int
+	for rows.Next() {
+		var entry LeaderboardEntry
+		if err := rows.Scan(&entry.Rank, &entry.UserId, &entry.UserName, &entry.Rating, &entry.GamesPlayed, &total); err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, total, rows.Err()
+}
+
+// tagStandings replays games in the order they were played with the usual
+// rating rules, starting everyone at the default rating, and ranks the
+// players in usernames that played at least minGames of them.
+func tagStandings(games []completedGame, usernames map[int]string, minGames int) []LeaderboardEntry {
+	ratings := map[int]int{}
+	played := map[int]int{}
+	for _, game := range games {
+		winners, losers := game.result()
+		for _, userid := range append(append([]int{}, winners...), losers...) {
+			if _, ok := ratings[userid]; !ok {
+				ratings[userid] = rating.DefaultRating
+			}
+			played[userid]++
+		}
+
+		delta := rating.Delta(
+			rating.TeamRating(teamRatings(ratings, winners)),
+			rating.TeamRating(teamRatings(ratings, losers)),
+		)
+		for _, userid := range winners {
+			ratings[userid] += delta
+		}
+		for _, userid := range losers {
+			ratings[userid] -= delta
+		}
+	}
+
+	var entries []LeaderboardEntry
+	for userid, r := range ratings {
+		username, ok := usernames[userid]
+		if !ok || played[userid] < minGames {
+			continue
+		}
+		entries = append(entries, LeaderboardEntry{UserId: userid, UserName: username, Rating: r, GamesPlayed: played[userid]})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Rating != entries[j].Rating {
+			return entries[i].Rating > entries[j].Rating
+		}
+		return entries[i].UserName < entries[j].UserName
+	})
+	for i := range entries {
+		if i > 0 && entries[i].Rating == entries[i-1].Rating {
+			entries[i].Rank = entries[i-1].Rank
+		} else {
+			entries[i].Rank = i + 1
+		}
+	}
+
+	return entries
+}
+
+// getTaggedLeaderboard ranks the season's members on only the completed
+// games tagged with tagid, instead of their season ratings.
+func (h *Handlers) getTaggedLeaderboard(scope leaderboardScope, tagid int, page Pagination) ([]LeaderboardEntry, int, error) {
+	queryGames := `SELECT g.team1_player1, g.team1_player2, g.team2_player1, g.team2_player2, g.team1_score, g.team2_score
+                   FROM games g
+                   JOIN gametagassignments ga ON ga.gameid = g.gameid
+                   WHERE g.orgid = {orgid} AND g.seasonid = $1 AND ga.tagid = $2 AND g.status = 'completed'
+                   ORDER BY g.createdat, g.gameid`
+	rows, err := queryOrg(h.db, scope.OrgId, queryGames, scope.SeasonId, tagid)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var games []completedGame
+	var players []int
+	for rows.Next() {
+		var game completedGame
+		var team1Player1, team2Player1 int
+		var team1Player2, team2Player2 *int
+		if err := rows.Scan(&team1Player1, &team1Player2, &team2Player1, &team2Player2, &game.Team1Score, &game.Team2Score); err != nil {
+			return nil, 0, err
+		}
+		game.Team1 = []int{team1Player1}
+		if team1Player2 != nil {
+			game.Team1 = append(game.Team1, *team1Player2)
+		}
+		game.Team2 = []int{team2Player1}
+		if team2Player2 != nil {
+			game.Team2 = append(game.Team2, *team2Player2)
+		}
+		games = append(games, game)
+		players = append(append(players, game.Team1...), game.Team2...)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	usernames := map[int]string{}
+	queryMembers := "SELECT userid, username FROM users WHERE activeorg = {orgid} AND userid = ANY($1)"
+	memberRows, err := queryOrg(h.db, scope.OrgId, queryMembers, pq.Array(players))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer memberRows.Close()
+
+	for memberRows.Next() {
+		var userid int
+		var username string
+		if err := memberRows.Scan(&userid, &username); err != nil {
+			return nil, 0, err
+		}
+		usernames[userid] = username
+	}
+	if err := memberRows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	entries := tagStandings(games, usernames, scope.MinGames)
+	total := len(entries)
+	start := min(page.Offset(), total)
+	end := min(start+page.Limit, total)
+	return entries[start:end], total, nil
+}
+
+// GetLeaderboard ranks the active season by rating. With ?tag= it only
+// looks at games with that tag, which must be one of the org's tags.
+func (h *Handlers) GetLeaderboard(c *fiber.Ctx) error {
+	scope, status, msg := h.activeLeaderboardScope(c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+
+	page := parsePagination(c)
+
+	tag := strings.TrimSpace(c.Query("tag"))
+	if tag != "" {
+		tags, err := h.resolveTags(scope.OrgId, []string{tag})
+		if err != nil {
+			log.Printf("Database query error: %v", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
+		}
+		if tags == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Tag must be a tag of your org",
+			})
+		}
+
+		entries, total, err := h.getTaggedLeaderboard(scope, tags[0].TagId, page)
+		if err != nil {
+			log.Printf("Database query error: %v", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
+		}
+		return respondPaginated(c, entries, total, page)
+	}
+
+	entries, total, err := h.getLeaderboard(scope.SeasonId, scope.MinGames, page)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
+	}
+
+	return respondPaginated(c, entries, total, page)
+}
+
+func (h *Handlers) GetPlayerRank(c *fiber.Ctx) error {
+	scope, status, msg := h.activeLeaderboardScope(c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+
+	userID, err := strconv.Atoi(c.Query("userid", userIdFromToken(c)))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid userid",
+		})
+	}
+
+	members, err := h.countOrgMembers(scope.OrgId, []int{userID})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if members != 1 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User is not a member of the org",
+		})
+	}
+
+	var rank, total int
+	query := rankedPlayersQuery + ` SELECT rank, total FROM ranked WHERE userid = $3`
+	err = h.db.QueryRow(query, scope.SeasonId, scope.MinGames, userID).Scan(&rank, &total)
+	if err == sql.ErrNoRows {
+		var played int
+		queryPlayed := "SELECT COUNT(*) FROM gameparticipants WHERE userid = $1 AND seasonid = $2 AND status = 'completed'"
+		if err := h.db.QueryRow(queryPlayed, userID, scope.SeasonId).Scan(&played); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Database error",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"userid":      userID,
+			"ranked":      false,
+			"reason":      fmt.Sprintf("Needs %d completed games this season to be ranked", scope.MinGames),
+			"gamesplayed": played,
+			"mingames":    scope.MinGames,
+		})
+	} else if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"userid":     userID,
+		"ranked":     true,
+		"rank":       rank,
+		"total":      total,
+		"toppercent": math.Max(1, math.Round(float64(rank)/float64(total)*100)),
+		"percentile": math.Round(float64(total-rank) / float64(total) * 100),
+	})
+}
+
+type WinProbability struct {
+	UserId         int     `json:"userid"`
+	Rating         int     `json:"rating"`
+	WinProbability float64 `json:"winprobability"`
+}
+
+func (h *Handlers) GetWinProbability(c *fiber.Ctx) error {
+	scope, status, msg := h.activeLeaderboardScope(c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+
+	userids, err := parseIdList(c.Query("userids"))
+	if err != nil || len(userids) != 2 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Exactly two userids are required",
+		})
+	}
+	if userids[0] == userids[1] {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Cannot compare a player with themselves",
+		})
+	}
+
+	members, err := h.countOrgMembers(scope.OrgId, userids)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if members != len(userids) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Both players must be members of the org",
+		})
+	}
+
+	ratings, err := getRatings(h.db, scope.SeasonId, userids)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	players := make([]WinProbability, len(userids))
+	for i, userid := range userids {
+		opponent := userids[1-i]
+		players[i] = WinProbability{
+			UserId:         userid,
+			Rating:         ratings[userid],
+			WinProbability: rating.WinProbability(float64(ratings[userid]), float64(ratings[opponent])),
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"seasonid": scope.SeasonId,
+		"players":  players,
+	})
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"pedersandvoll/foosballapi/rating"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestTagStandingsReplaysTaggedGames(t *testing.T) {
+	games := []completedGame{
+		{Team1: []int{1}, Team2: []int{2}, Team1Score: 10, Team2Score: 4},
+		{Team1: []int{1}, Team2: []int{3}, Team1Score: 2, Team2Score: 10},
+		{Team1: []int{4}, Team2: []int{2}, Team1Score: 10, Team2Score: 8},
+	}
+	// 4 isn't a member anymore, so only their opponents' results count.
+	usernames := map[int]string{1: "alice", 2: "bob", 3: "carol"}
+
+	entries := tagStandings(games, usernames, 1)
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 ranked members, got %v", entries)
+	}
+
+	first := rating.Delta(rating.DefaultRating, rating.DefaultRating)
+	alice := rating.DefaultRating + first
+	second := rating.Delta(rating.DefaultRating, float64(alice))
+	want := []LeaderboardEntry{
+		{Rank: 1, UserId: 3, UserName: "carol", Rating: rating.DefaultRating + second, GamesPlayed: 1},
+		{Rank: 2, UserId: 1, UserName: "alice", Rating: alice - second, GamesPlayed: 2},
+	}
+	for i, entry := range want {
+		if entries[i] != entry {
+			t.Fatalf("Entry %d: expected %+v, got %+v", i, entry, entries[i])
+		}
+	}
+	if entries[2].UserId != 2 || entries[2].GamesPlayed != 2 {
+		t.Fatalf("Expected bob last with 2 games, got %+v", entries[2])
+	}
+
+	if ranked := tagStandings(games, usernames, 2); len(ranked) != 2 {
+		t.Fatalf("Expected only players with 2 tagged games, got %v", ranked)
+	}
+}
+
+func TestGetLeaderboardByTag(t *testing.T) {
+	db := testDB(t)
+	org := seedOrg(t, db, 3)
+	if _, err := db.Exec("UPDATE organizationsettings SET minrankedgames = 1 WHERE orgid = $1", org.OrgId); err != nil {
+		t.Fatalf("Could not set min ranked games: %v", err)
+	}
+
+	var tagid int
+	if err := db.QueryRow("INSERT INTO gametags (orgid, name) VALUES ($1, 'cup') RETURNING tagid", org.OrgId).Scan(&tagid); err != nil {
+		t.Fatalf("Could not create tag: %v", err)
+	}
+
+	queryGame := `INSERT INTO games (orgid, seasonid, team1_player1, team2_player1, team1_score, team2_score, status, reported_by, submitted_by)
+                  VALUES ($1, $2, $3, $4, 10, 5, 'completed', $3, $3) RETURNING gameid`
+	var tagged int
+	if err := db.QueryRow(queryGame, org.OrgId, org.SeasonId, org.Members[0], org.Members[1]).Scan(&tagged); err != nil {
+		t.Fatalf("Could not create tagged game: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO gametagassignments (gameid, tagid) VALUES ($1, $2)", tagged, tagid); err != nil {
+		t.Fatalf("Could not tag game: %v", err)
+	}
+	var untagged int
+	if err := db.QueryRow(queryGame, org.OrgId, org.SeasonId, org.Members[2], org.Members[1]).Scan(&untagged); err != nil {
+		t.Fatalf("Could not create untagged game: %v", err)
+	}
+
+	h := &Handlers{db: db}
+	app := testApp(fiber.MethodGet, "/leaderboard", h.GetLeaderboard, org.Owner, org.OrgId)
+
+	status, body := doJSON(t, app, fiber.MethodGet, "/leaderboard?tag=CUP&envelope=true", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", status, body)
+	}
+	if body["total"] != float64(2) {
+		t.Fatalf("Expected only the tagged game's players, got %v", body)
+	}
+	data, _ := body["data"].([]interface{})
+	if top, _ := data[0].(map[string]interface{}); top["userid"] != float64(org.Members[0]) {
+		t.Fatalf("Expected the tagged game's winner on top, got %v", data)
+	}
+
+	if status, body := doJSON(t, app, fiber.MethodGet, "/leaderboard?tag=unknown", nil); status != fiber.StatusBadRequest {
+		t.Fatalf("Expected 400 for a tag the org doesn't have, got %d: %v", status, body)
+	}
+}
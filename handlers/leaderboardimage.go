@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	DefaultLeaderboardImageSize = 10
+	MaxLeaderboardImageSize     = 25
+
+	leaderboardImageWidth     = 420
+	leaderboardImageRowHeight = 28
+	leaderboardImageHeader    = 48
+)
+
+type cachedImage struct {
+	version string
+	body    []byte
+}
+
+// imageCache keeps the last rendered image per key, and is only reused while
+// the version it was rendered for is still current.
+type imageCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedImage
+}
+
+func newImageCache() *imageCache {
+	return &imageCache{entries: map[string]cachedImage{}}
+}
+
+func (ic *imageCache) get(key string, version string) ([]byte, bool) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	entry, ok := ic.entries[key]
+	if !ok || entry.version != version {
+		return nil, false
+	}
+	return entry.body, true
+}
+
+func (ic *imageCache) set(key string, version string, body []byte) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.entries[key] = cachedImage{version: version, body: body}
+}
+
+func renderLeaderboardSVG(entries []LeaderboardEntry, background string, foreground string) []byte {
+	height := leaderboardImageHeader + leaderboardImageRowHeight*len(entries) + 12
+	if len(entries) == 0 {
+		height += leaderboardImageRowHeight
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`,
+		leaderboardImageWidth, height, leaderboardImageWidth, height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`, html.EscapeString(background))
+	fmt.Fprintf(&b, `<g fill="%s">`, html.EscapeString(foreground))
+	fmt.Fprintf(&b, `<text x="16" y="32" font-size="20" font-weight="bold">Leaderboard</text>`)
+	fmt.Fprintf(&b, `<rect x="16" y="40" width="%d" height="2"/>`, leaderboardImageWidth-32)
+
+	if len(entries) == 0 {
+		fmt.Fprintf(&b, `<text x="16" y="%d" font-size="14">No ranked players yet</text>`, leaderboardImageHeader+20)
+	}
+	for i, entry := range entries {
+		y := leaderboardImageHeader + leaderboardImageRowHeight*i + 20
+		fmt.Fprintf(&b, `<text x="16" y="%d" font-size="14" font-weight="bold">%d.</text>`, y, entry.Rank)
+		fmt.Fprintf(&b, `<text x="56" y="%d" font-size="14">%s</text>`, y, html.EscapeString(entry.UserName))
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="14" text-anchor="end">%d</text>`, leaderboardImageWidth-16, y, entry.Rating)
+	}
+
+	b.WriteString(`</g></svg>`)
+	return b.Bytes()
+}
+
+func (h *Handlers) GetLeaderboardImage(c *fiber.Ctx) error {
+	scope, status, msg := h.activeLeaderboardScope(c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+
+	limit := c.QueryInt("limit", DefaultLeaderboardImageSize)
+	if limit < 1 || limit > MaxLeaderboardImageSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Limit must be between 1 and %d", MaxLeaderboardImageSize),
+		})
+	}
+
+	background, foreground := DefaultTeam1Color, DefaultTeam2Color
+	settings, err := h.getOrgSettings(scope.OrgId)
+	if err == nil {
+		if settings.Team1Color != nil {
+			background = *settings.Team1Color
+		}
+		if settings.Team2Color != nil {
+			foreground = *settings.Team2Color
+		}
+	}
+
+	// Writers to playerratings are expected to bump updated_at. The row count,
+	// rating sum and last decay are part of the version as well, so a writer
+	// that forgets still changes it in practice.
+	var lastChanged, lastDecayed *time.Time
+	var rows, members, ratingSum int
+	queryChanged := `SELECT MAX(pr.updated_at), MAX(pr.last_decay_at), COUNT(*), COUNT(u.userid), COALESCE(SUM(pr.rating), 0)
+                     FROM playerratings pr
+                     LEFT JOIN users u ON u.userid = pr.userid AND u.activeorg = pr.orgid
+                     WHERE pr.seasonid = $1`
+	err = h.db.QueryRow(queryChanged, scope.SeasonId).Scan(&lastChanged, &lastDecayed, &rows, &members, &ratingSum)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	key := fmt.Sprintf("%s:%d", scope.OrgId, limit)
+	version := fmt.Sprintf("%d:%d:%s:%s:%d:%d:%d", scope.SeasonId, scope.MinGames, background, foreground, rows, members, ratingSum)
+	for _, changed := range []*time.Time{lastChanged, lastDecayed} {
+		version += ":"
+		if changed != nil {
+			version += changed.UTC().Format(time.RFC3339Nano)
+		}
+	}
+
+	body, ok := h.leaderboardImages.get(key, version)
+	if !ok {
+		entries, _, err := h.getLeaderboard(scope.SeasonId, scope.MinGames, Pagination{Page: 1, Limit: limit})
+		if err != nil {
+			log.Printf("Database query error: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Database error",
+			})
+		}
+		body = renderLeaderboardSVG(entries, background, foreground)
+		h.leaderboardImages.set(key, version, body)
+	}
+
+	c.Set(fiber.HeaderContentType, "image/svg+xml")
+	return c.Send(body)
+}
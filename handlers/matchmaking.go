@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"log"
+	"pedersandvoll/foosballapi/rating"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FindMatchBody struct {
+	CreateLobby bool `json:"createlobby"`
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// closestOpponent picks the available player nearest to the given rating.
+// Players come sorted by username, so ties go to the first one alphabetically.
+func closestOpponent(players []AvailablePlayer, userid int, myRating int) (AvailablePlayer, bool) {
+	var best AvailablePlayer
+	found := false
+	for _, player := range players {
+		if player.UserId == userid {
+			continue
+		}
+		if !found || abs(player.Rating-myRating) < abs(best.Rating-myRating) {
+			best = player
+			found = true
+		}
+	}
+	return best, found
+}
+
+func (h *Handlers) FindMatch(c *fiber.Ctx) error {
+	var body FindMatchBody
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	scope, status, msg := h.activeLeaderboardScope(c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+
+	userID := userIdFromToken(c)
+	userid, err := strconv.Atoi(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Invalid userid format",
+		})
+	}
+
+	ratings, err := getRatings(h.db, scope.SeasonId, []int{userid})
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	myRating := ratings[userid]
+
+	players, err := h.getAvailablePlayers(scope.OrgId, &scope.SeasonId)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	opponent, found := closestOpponent(players, userid, myRating)
+	if !found {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	response := fiber.Map{
+		"seasonid":       scope.SeasonId,
+		"rating":         myRating,
+		"opponent":       opponent,
+		"winprobability": rating.WinProbability(float64(myRating), float64(opponent.Rating)),
+	}
+
+	if body.CreateLobby {
+		if _, status, msg := h.requireUnsuspendedOrg(c, scope.OrgId); status != 0 {
+			return c.Status(status).JSON(fiber.Map{"error": msg})
+		}
+
+		lobbyId, err := h.createLobby(scope.OrgId, scope.SeasonId, userID)
+		if err != nil {
+			return respondTxError(c, err, "Failed to create lobby")
+		}
+		response["lobbyid"] = lobbyId
+	}
+
+	return c.JSON(response)
+}
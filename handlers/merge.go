@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MergeOrganizationsBody struct {
+	SourceOrgId int `json:"sourceorgid"`
+	TargetOrgId int `json:"targetorgid"`
+}
+
+type mergeStep struct {
+	Name  string
+	Query string
+}
+
+// Seasons keep their ids, so games and ratings stay attached to them and the
+// moved ratings are still valid for the seasons they were earned in.
+// Renamed rows are cut to their column's length, which can make them clash
+// again; the merge is then rejected and rolled back.
+var mergeSteps = []mergeStep{
+	{"seasonsrenamed", `UPDATE seasons s SET name = LEFT(s.name || ' (' || o.name || ')', 255)
+                        FROM organizations o
+                        WHERE o.orgid = $1 AND s.orgid = $1
+                        AND s.name IN (SELECT name FROM seasons WHERE orgid = $2)`},
+	{"seasons", "UPDATE seasons SET orgid = $2 WHERE orgid = $1"},
+	{"games", "UPDATE games SET orgid = $2 WHERE orgid = $1"},
+	{"ratings", "UPDATE playerratings SET orgid = $2, updated_at = NOW() WHERE orgid = $1"},
+	{"lobbies", "UPDATE lobbies SET orgid = $2 WHERE orgid = $1"},
+	{"fixtures", "UPDATE fixtures SET orgid = $2 WHERE orgid = $1"},
+	{"streakmilestones", "UPDATE streakmilestones SET orgid = $2 WHERE orgid = $1"},
+	{"duplicatetagassignments", `UPDATE gametagassignments ga SET tagid = t.tagid
+                                 FROM gametags s
+                                 JOIN gametags t ON t.orgid = $2 AND LOWER(t.name) = LOWER(s.name)
+                                 WHERE s.orgid = $1 AND ga.tagid = s.tagid`},
+	{"duplicatetags", `DELETE FROM gametags
+                       WHERE orgid = $1 AND LOWER(name) IN (SELECT LOWER(name) FROM gametags WHERE orgid = $2)`},
+	{"tags", "UPDATE gametags SET orgid = $2 WHERE orgid = $1"},
+	{"presetsrenamed", `UPDATE gamepresets p SET name = LEFT(p.name || ' (' || o.name || ')', 50)
+                        FROM organizations o
+                        WHERE o.orgid = $1 AND p.orgid = $1
+                        AND LOWER(p.name) IN (SELECT LOWER(name) FROM gamepresets WHERE orgid = $2 AND userid = p.userid)`},
+	{"presets", "UPDATE gamepresets SET orgid = $2 WHERE orgid = $1"},
+	{"duplicateavailability", `DELETE FROM playeravailability
+                               WHERE orgid = $1 AND userid IN (SELECT userid FROM playeravailability WHERE orgid = $2)`},
+	{"availability", "UPDATE playeravailability SET orgid = $2 WHERE orgid = $1"},
+	{"provisionalrenamed", `UPDATE users u SET username = LEFT(u.username || ' (' || o.name || ')', 255)
+                            FROM organizations o
+                            WHERE o.orgid = $1 AND u.activeorg = $1 AND u.provisional
+                            AND LOWER(u.username) IN (SELECT LOWER(username) FROM users WHERE activeorg = $2 AND provisional)`},
+	{"members", "UPDATE users SET activeorg = $2 WHERE activeorg = $1"},
+}
+
+func (h *Handlers) MergeOrganizations(c *fiber.Ctx) error {
+	if !h.allowOrgMerge {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Merging organizations is disabled",
+		})
+	}
+
+	var body MergeOrganizationsBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if body.SourceOrgId == 0 || body.TargetOrgId == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Source and target org are required",
+		})
+	}
+	if body.SourceOrgId == body.TargetOrgId {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Cannot merge an org into itself",
+		})
+	}
+
+	userID := userIdFromToken(c)
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	queryLock := `SELECT COUNT(*) FROM (
+                      SELECT o.orgid FROM organizations o
+                      JOIN organizationsettings s ON s.orgid = o.orgid
+                      WHERE o.orgid IN ($1, $2) AND o.deleted_at IS NULL AND s.orgowner = $3
+                      FOR UPDATE OF o
+                  ) owned`
+	var owned int
+	if err := tx.QueryRow(queryLock, body.SourceOrgId, body.TargetOrgId, userID).Scan(&owned); err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if owned != 2 {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "You must own both organizations to merge them",
+		})
+	}
+
+	summary := fiber.Map{}
+	for _, step := range mergeSteps {
+		result, err := tx.Exec(step.Query, body.SourceOrgId, body.TargetOrgId)
+		if err != nil && strings.Contains(err.Error(), "unique constraint") {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": fmt.Sprintf("Could not merge %s without a name clash, rename them first", step.Name),
+			})
+		} else if err != nil {
+			log.Printf("Failed to merge %s: %v", step.Name, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to merge organizations",
+			})
+		}
+		moved, _ := result.RowsAffected()
+		summary[step.Name] = moved
+	}
+
+	queryRevokeKiosks := "UPDATE kiosktokens SET revoked_at = NOW() WHERE orgid = $1 AND revoked_at IS NULL"
+	if _, err := tx.Exec(queryRevokeKiosks, body.SourceOrgId); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to revoke source kiosk tokens",
+		})
+	}
+
+	querySoftDelete := "UPDATE organizations SET deleted_at = NOW(), activeseason = NULL WHERE orgid = $1"
+	if _, err := tx.Exec(querySoftDelete, body.SourceOrgId); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete source organization",
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to merge organizations",
+		})
+	}
+
+	// Tokens carry the active org and a refresh keeps it, so members of the
+	// source org only see the target org after logging in again.
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Organizations merged successfully. Members of the source organization must log in again",
+		"summary": summary,
+	})
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 100
+)
+
+type Pagination struct {
+	Page  int
+	Limit int
+}
+
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// LimitArg is the value for a LIMIT parameter. Postgres treats a NULL limit
+// as no limit at all.
+func (p Pagination) LimitArg() interface{} {
+	if p.Limit == 0 {
+		return nil
+	}
+	return p.Limit
+}
+
+func parsePagination(c *fiber.Ctx) Pagination {
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+
+	limit := c.QueryInt("limit", DefaultPageSize)
+	if limit < 1 || limit > MaxPageSize {
+		limit = DefaultPageSize
+	}
+
+	return Pagination{Page: page, Limit: limit}
+}
+
+// parseOptionalPagination is for lists that returned every row before they
+// could be paged. Unless page or limit is passed the whole list comes back,
+// so older clients don't silently lose rows.
+func parseOptionalPagination(c *fiber.Ctx) Pagination {
+	if c.Query("page") == "" && c.Query("limit") == "" {
+		return Pagination{Page: 1}
+	}
+	return parsePagination(c)
+}
+
+// wantsEnvelope reports whether the client asked for the wrapped page format,
+// either with ?envelope=true or an Accept header like
+// `application/json; profile="envelope"`. The bare array is the default.
+func wantsEnvelope(c *fiber.Ctx) bool {
+	if envelope, err := strconv.ParseBool(c.Query("envelope")); err == nil {
+		return envelope
+	}
+
+	for _, param := range strings.Split(c.Get(fiber.HeaderAccept), ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if found && strings.EqualFold(key, "profile") {
+			for _, profile := range strings.Fields(strings.Trim(value, `"`)) {
+				if profile == "envelope" {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func respondPaginated(c *fiber.Ctx, data interface{}, total int, p Pagination) error {
+	if wantsEnvelope(c) {
+		return c.JSON(fiber.Map{
+			"data":  data,
+			"total": total,
+			"page":  p.Page,
+		})
+	}
+
+	c.Set("X-Total-Count", strconv.Itoa(total))
+	c.Set("X-Page", strconv.Itoa(p.Page))
+	if p.Limit > 0 {
+		c.Set("X-Per-Page", strconv.Itoa(p.Limit))
+	}
+
+	return c.JSON(data)
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"pedersandvoll/foosballapi/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ChangePasswordBody struct {
+	CurrentPassword string `json:"currentpassword"`
+	NewPassword     string `json:"newpassword"`
+}
+
+// ChangePassword keeps the bcrypt hashes of replaced passwords when password
+// history is enabled. The current password counts as one of the last N, so
+// only N-1 older hashes are kept.
+func (h *Handlers) ChangePassword(c *fiber.Ctx) error {
+	var body ChangePasswordBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if body.CurrentPassword == "" || body.NewPassword == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Current and new password are required",
+		})
+	}
+
+	userID := userIdFromToken(c)
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	var currentHash string
+	err = tx.QueryRow("SELECT password FROM users WHERE userid = $1 AND NOT provisional FOR UPDATE", userID).Scan(&currentHash)
+	if err == sql.ErrNoRows {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	if !utils.VerifyPassword(body.CurrentPassword, currentHash) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Current password is wrong",
+		})
+	}
+	if utils.VerifyPassword(body.NewPassword, currentHash) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "New password must be different from the current password",
+		})
+	}
+
+	if h.passwordHistory > 1 {
+		queryHistory := "SELECT password FROM passwordhistory WHERE userid = $1 ORDER BY created_at DESC, historyid DESC LIMIT $2"
+		rows, err := tx.Query(queryHistory, userID, h.passwordHistory-1)
+		if err != nil {
+			log.Printf("Database query error: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Database error",
+			})
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var hash string
+			if err := rows.Scan(&hash); err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Database error",
+				})
+			}
+			if utils.VerifyPassword(body.NewPassword, hash) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": fmt.Sprintf("New password cannot be one of your last %d passwords", h.passwordHistory),
+				})
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Database error",
+			})
+		}
+		rows.Close()
+	}
+
+	hashedPassword, err := utils.HashPassword(body.NewPassword)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to hash password",
+		})
+	}
+
+	if _, err := tx.Exec("UPDATE users SET password = $1 WHERE userid = $2", hashedPassword, userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to change password",
+		})
+	}
+
+	if h.passwordHistory > 1 {
+		if _, err := tx.Exec("INSERT INTO passwordhistory (userid, password) VALUES ($1, $2)", userID, currentHash); err != nil {
+			log.Printf("Database query error: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to store password history",
+			})
+		}
+	}
+
+	queryPrune := `DELETE FROM passwordhistory
+                   WHERE userid = $1 AND historyid NOT IN (
+                       SELECT historyid FROM passwordhistory WHERE userid = $1
+                       ORDER BY created_at DESC, historyid DESC LIMIT $2
+                   )`
+	keep := h.passwordHistory - 1
+	if keep < 0 {
+		keep = 0
+	}
+	if _, err := tx.Exec(queryPrune, userID, keep); err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to prune password history",
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to change password",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Password changed successfully",
+	})
+}
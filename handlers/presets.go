@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+)
+
+const MaxPresetNameLength = 50
+
+type Preset struct {
+	PresetId int    `json:"presetid"`
+	Name     string `json:"name"`
+	Team1    []int  `json:"team1"`
+	Team2    []int  `json:"team2"`
+}
+
+type CreatePresetBody struct {
+	Name  string `json:"name"`
+	Team1 []int  `json:"team1"`
+	Team2 []int  `json:"team2"`
+}
+
+func scanPreset(row interface{ Scan(...interface{}) error }) (Preset, error) {
+	var preset Preset
+	var team1, team2 pq.Int64Array
+	if err := row.Scan(&preset.PresetId, &preset.Name, &team1, &team2); err != nil {
+		return Preset{}, err
+	}
+	for _, id := range team1 {
+		preset.Team1 = append(preset.Team1, int(id))
+	}
+	for _, id := range team2 {
+		preset.Team2 = append(preset.Team2, int(id))
+	}
+	return preset, nil
+}
+
+// getPreset only finds presets saved by userid in orgid.
+func (h *Handlers) getPreset(orgid string, userid string, presetid int) (Preset, error) {
+	query := "SELECT presetid, name, team1, team2 FROM gamepresets WHERE presetid = $1 AND userid = $2 AND orgid = {orgid}"
+	query, args, err := scopedQuery(orgid, query, presetid, userid)
+	if err != nil {
+		return Preset{}, err
+	}
+	return scanPreset(h.db.QueryRow(query, args...))
+}
+
+func (h *Handlers) CreatePreset(c *fiber.Ctx) error {
+	var body CreatePresetBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" || len(body.Name) > MaxPresetNameLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Preset name must be between 1 and 50 characters",
+		})
+	}
+
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	maxTeamSize, err := h.getMaxTeamSize(activeOrgStr)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if msg := validateTeams(body.Team1, body.Team2, maxTeamSize); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	players := append(append([]int{}, body.Team1...), body.Team2...)
+	members, err := h.countOrgMembers(activeOrgStr, players)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if members != len(players) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "All players must be members of the org",
+		})
+	}
+
+	var presetId int
+	query := "INSERT INTO gamepresets (orgid, userid, name, team1, team2) VALUES ($1, $2, $3, $4, $5) RETURNING presetid"
+	err = h.db.QueryRow(query, activeOrgStr, userIdFromToken(c), body.Name, pq.Array(body.Team1), pq.Array(body.Team2)).Scan(&presetId)
+	if err != nil {
+		if strings.Contains(err.Error(), "unique constraint") {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "You already have a preset with that name",
+			})
+		}
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create preset",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":  "Preset created successfully",
+		"presetid": presetId,
+	})
+}
+
+func (h *Handlers) GetPresets(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	query := "SELECT presetid, name, team1, team2 FROM gamepresets WHERE userid = $1 AND orgid = {orgid} ORDER BY name"
+	rows, err := queryOrg(h.db, activeOrgStr, query, userIdFromToken(c))
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	defer rows.Close()
+
+	presets := []Preset{}
+	for rows.Next() {
+		preset, err := scanPreset(rows)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to scan preset",
+			})
+		}
+		presets = append(presets, preset)
+	}
+	if err := rows.Err(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Error iterating presets",
+		})
+	}
+
+	return c.JSON(presets)
+}
+
+// applyPreset fills the game's teams from a saved preset. Players can leave
+// the org after the preset was saved, so membership is checked again here.
+func (h *Handlers) applyPreset(orgid string, userid string, body *CreateGameBody) (int, string) {
+	if len(body.Team1) > 0 || len(body.Team2) > 0 || len(body.Team1New) > 0 || len(body.Team2New) > 0 {
+		return fiber.StatusBadRequest, "Pass either a preset or teams, not both"
+	}
+
+	preset, err := h.getPreset(orgid, userid, *body.PresetId)
+	if err == sql.ErrNoRows {
+		return fiber.StatusNotFound, "Preset not found"
+	} else if err != nil {
+		log.Printf("Database query error: %v", err)
+		return fiber.StatusInternalServerError, "Database error"
+	}
+
+	players := append(append([]int{}, preset.Team1...), preset.Team2...)
+	members, err := h.countOrgMembers(orgid, players)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return fiber.StatusInternalServerError, "Database error"
+	}
+	if members != len(players) {
+		return fiber.StatusBadRequest, "Some players in the preset are no longer members of the org"
+	}
+
+	body.Team1 = preset.Team1
+	body.Team2 = preset.Team2
+	return 0, ""
+}
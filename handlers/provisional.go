@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"pedersandvoll/foosballapi/rating"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+)
+
+const MaxProvisionalNameLength = 255
+
+func normalizeProvisionalNames(team1, team2 []string) ([]string, []string, string) {
+	seen := map[string]bool{}
+	clean := func(names []string) ([]string, string) {
+		var out []string
+		for _, name := range names {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				return nil, "Provisional player names cannot be empty"
+			}
+			if len(name) > MaxProvisionalNameLength {
+				return nil, "Provisional player names can be at most 255 characters"
+			}
+			if seen[strings.ToLower(name)] {
+				return nil, "A provisional player can only be on one team once"
+			}
+			seen[strings.ToLower(name)] = true
+			out = append(out, name)
+		}
+		return out, ""
+	}
+
+	team1, msg := clean(team1)
+	if msg != "" {
+		return nil, nil, msg
+	}
+	team2, msg = clean(team2)
+	return team1, team2, msg
+}
+
+// checkProvisionalNames rejects names that belong to a registered member of
+// the org, since those have to be entered by userid instead.
+func (h *Handlers) checkProvisionalNames(orgid string, names []string) error {
+	queryMember := `SELECT username FROM users
+                    WHERE activeorg = $1 AND NOT provisional AND LOWER(username) = ANY(SELECT LOWER(unnest($2::text[])))
+                    LIMIT 1`
+	var taken string
+	err := h.db.QueryRow(queryMember, orgid, pq.Array(names)).Scan(&taken)
+	if err == nil {
+		return &requestError{fiber.StatusBadRequest, fmt.Sprintf("%s already has an account, enter them by userid", taken)}
+	} else if err != sql.ErrNoRows {
+		return err
+	}
+	return nil
+}
+
+// resolveProvisionalPlayers returns the ids of the org's provisional players
+// with the given names, creating the ones that don't exist yet. Names are
+// matched case-insensitively. New players are recorded as created by
+// createdBy, who may hand them to a member later. Kiosks pass nil.
+func resolveProvisionalPlayers(tx *sql.Tx, orgid string, createdBy *int, names []string) ([]int, error) {
+	queryCreate := `INSERT INTO users (username, activeorg, provisional, joined_at, created_by) VALUES ($1, $2, TRUE, NOW(), $3)
+                    ON CONFLICT DO NOTHING RETURNING userid`
+	queryExisting := "SELECT userid FROM users WHERE provisional AND activeorg = $1 AND LOWER(username) = LOWER($2)"
+
+	ids := make([]int, 0, len(names))
+	for _, name := range names {
+		var id int
+		err := tx.QueryRow(queryCreate, name, orgid, createdBy).Scan(&id)
+		if err == sql.ErrNoRows {
+			err = tx.QueryRow(queryExisting, orgid, name).Scan(&id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// ClaimPlayerBody hands the provisional player UserId over to MemberId, the
+// registered member it turned out to be. MemberId defaults to the caller.
+type ClaimPlayerBody struct {
+	UserId   int `json:"userid"`
+	MemberId int `json:"memberid"`
+}
+
+// Every step moves rows from the provisional player ($1) to the member
+// claiming it ($2). Ratings earned in a season both of them played are
+// combined by adding the provisional player's gain or loss to the member's
+// rating.
+var claimSteps = []mergeStep{
+	{"team1player1", "UPDATE games SET team1_player1 = $2 WHERE team1_player1 = $1"},
+	{"team1player2", "UPDATE games SET team1_player2 = $2 WHERE team1_player2 = $1"},
+	{"team2player1", "UPDATE games SET team2_player1 = $2 WHERE team2_player1 = $1"},
+	{"team2player2", "UPDATE games SET team2_player2 = $2 WHERE team2_player2 = $1"},
+	{"spectated", "UPDATE gamespectators SET userid = $2 WHERE userid = $1"},
+	{"fixture1player1", "UPDATE fixtures SET team1_player1 = $2 WHERE team1_player1 = $1"},
+	{"fixture1player2", "UPDATE fixtures SET team1_player2 = $2 WHERE team1_player2 = $1"},
+	{"fixture2player1", "UPDATE fixtures SET team2_player1 = $2 WHERE team2_player1 = $1"},
+	{"fixture2player2", "UPDATE fixtures SET team2_player2 = $2 WHERE team2_player2 = $1"},
+	{"combinedratings", fmt.Sprintf(`UPDATE playerratings r SET rating = r.rating + p.rating - %d, updated_at = NOW()
+                                     FROM playerratings p
+                                     WHERE p.userid = $1 AND r.userid = $2 AND r.seasonid = p.seasonid`, rating.DefaultRating)},
+	{"duplicateratings", `DELETE FROM playerratings
+                          WHERE userid = $1 AND seasonid IN (SELECT seasonid FROM playerratings WHERE userid = $2)`},
+	{"ratings", "UPDATE playerratings SET userid = $2, updated_at = NOW() WHERE userid = $1"},
+	{"streakmilestones", "UPDATE streakmilestones SET userid = $2 WHERE userid = $1"},
+	{"duplicateavailability", `DELETE FROM playeravailability
+                               WHERE userid = $1 AND orgid IN (SELECT orgid FROM playeravailability WHERE userid = $2)`},
+	{"availability", "UPDATE playeravailability SET userid = $2 WHERE userid = $1"},
+	{"presets", `UPDATE gamepresets SET team1 = array_replace(team1, $1, $2), team2 = array_replace(team2, $1, $2)
+                 WHERE $1 = ANY(team1) OR $1 = ANY(team2)`},
+}
+
+func (h *Handlers) ClaimPlayer(c *fiber.Ctx) error {
+	var body ClaimPlayerBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if body.UserId == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Userid of the provisional player is required",
+		})
+	}
+
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	userID := userIdFromToken(c)
+	member := body.MemberId
+	if member == 0 {
+		var err error
+		if member, err = strconv.Atoi(userID); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Invalid userid format",
+			})
+		}
+	}
+	memberID := strconv.Itoa(member)
+
+	isMember, err := h.isActiveOrgMember(activeOrgStr, member)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if !isMember {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Provisional players can only be claimed by registered members of the org",
+		})
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	var name string
+	var createdBy sql.NullString
+	queryLock := "SELECT username, created_by FROM users WHERE userid = $1 AND activeorg = $2 AND provisional FOR UPDATE"
+	err = tx.QueryRow(queryLock, body.UserId, activeOrgStr).Scan(&name, &createdBy)
+	if err == sql.ErrNoRows {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Provisional player not found in your org",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	// Claiming hands all of the player's games and ratings to the member, so
+	// the org owner or whoever added the player has to do it. A newly
+	// registered member asks one of them to claim the player for them.
+	if !createdBy.Valid || createdBy.String != userID {
+		isOwner, err := h.isOrgOwner(activeOrgStr, userID)
+		if err != nil {
+			log.Printf("Database query error: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Database error",
+			})
+		}
+		if !isOwner {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Only the org owner or the user who added this player can hand it to a member",
+			})
+		}
+	}
+
+	// Games, fixtures and presets that already have both players would end
+	// up with the member twice.
+	queryShared := `SELECT EXISTS (
+                        SELECT 1 FROM (
+                            SELECT 'game' AS kind, gameid AS id, userid FROM gameparticipants
+                            UNION ALL
+                            SELECT 'game', gameid, userid FROM gamespectators
+                            UNION ALL
+                            SELECT 'fixture', fixtureid, UNNEST(ARRAY[team1_player1, team1_player2, team2_player1, team2_player2]) FROM fixtures
+                            UNION ALL
+                            SELECT 'preset', presetid, UNNEST(team1 || team2) FROM gamepresets
+                        ) g
+                        WHERE g.userid IN ($1, $2)
+                        GROUP BY g.kind, g.id HAVING COUNT(DISTINCT g.userid) = 2
+                    )`
+	var shared bool
+	if err := tx.QueryRow(queryShared, body.UserId, memberID).Scan(&shared); err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if shared {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "The member shares a game, fixture or preset with this provisional player",
+		})
+	}
+
+	summary := fiber.Map{}
+	for _, step := range claimSteps {
+		result, err := tx.Exec(step.Query, body.UserId, memberID)
+		if err != nil {
+			log.Printf("Failed to claim %s: %v", step.Name, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to claim player",
+			})
+		}
+		moved, _ := result.RowsAffected()
+		summary[step.Name] = moved
+	}
+
+	if _, err := tx.Exec("DELETE FROM users WHERE userid = $1", body.UserId); err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to remove provisional player",
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to claim player",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  fmt.Sprintf("Claimed %s", name),
+		"memberid": member,
+		"summary":  summary,
+	})
+}
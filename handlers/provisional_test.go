@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestClaimPlayerHandsGamesToMember(t *testing.T) {
+	db := testDB(t)
+	org := seedOrg(t, db, 2)
+	creator, member := org.Members[0], org.Members[1]
+
+	var provisional int
+	queryProvisional := `INSERT INTO users (username, activeorg, provisional, joined_at, created_by)
+                         VALUES ($1, $2, TRUE, NOW(), $3) RETURNING userid`
+	if err := db.QueryRow(queryProvisional, "guest-"+org.OrgId, org.OrgId, creator).Scan(&provisional); err != nil {
+		t.Fatalf("Could not create provisional player: %v", err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM users WHERE userid = $1", provisional) })
+
+	queryGame := `INSERT INTO games (orgid, seasonid, team1_player1, team2_player1, team1_score, team2_score, status, reported_by, submitted_by)
+                  VALUES ($1, $2, $3, $4, 10, 5, 'completed', $3, $3)`
+	if _, err := db.Exec(queryGame, org.OrgId, org.SeasonId, creator, provisional); err != nil {
+		t.Fatalf("Could not create game: %v", err)
+	}
+
+	h := &Handlers{db: db}
+	body := fiber.Map{"userid": provisional, "memberid": member}
+
+	// The member can't take over the record on their own.
+	asMember := testApp(fiber.MethodPost, "/claim/player", h.ClaimPlayer, fmt.Sprint(member), org.OrgId)
+	if status, resp := doJSON(t, asMember, fiber.MethodPost, "/claim/player", body); status != fiber.StatusForbidden {
+		t.Fatalf("Expected 403 for a member claiming without approval, got %d: %v", status, resp)
+	}
+
+	// The creator played against the provisional player, but hands it to
+	// someone else, so the shared game check doesn't apply to them.
+	asCreator := testApp(fiber.MethodPost, "/claim/player", h.ClaimPlayer, fmt.Sprint(creator), org.OrgId)
+	if status, resp := doJSON(t, asCreator, fiber.MethodPost, "/claim/player", body); status != fiber.StatusOK {
+		t.Fatalf("Expected the creator to hand the player to the member, got %d: %v", status, resp)
+	}
+
+	var games int
+	if err := db.QueryRow("SELECT COUNT(*) FROM games WHERE seasonid = $1 AND team2_player1 = $2", org.SeasonId, member).Scan(&games); err != nil {
+		t.Fatalf("Could not count games: %v", err)
+	}
+	if games != 1 {
+		t.Fatalf("Expected the game to move to the member, found %d", games)
+	}
+}
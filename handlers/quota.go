@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+)
+
+const maxQuotaTxAttempts = 5
+
+type requestError struct {
+	Status  int
+	Message string
+}
+
+func (e *requestError) Error() string {
+	return e.Message
+}
+
+func respondTxError(c *fiber.Ctx, err error, fallback string) error {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		return c.Status(reqErr.Status).JSON(fiber.Map{
+			"error": reqErr.Message,
+		})
+	}
+
+	log.Printf("Database query error: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
+
+func isSerializationFailure(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "40001"
+}
+
+// runQuotaTx runs fn in a transaction for inserts that are capped by an org
+// quota. With serializable quotas enabled the transaction runs at SERIALIZABLE
+// and is retried on serialization failures, otherwise the quota checks lock
+// the org's settings row instead.
+func (h *Handlers) runQuotaTx(fn func(tx *sql.Tx) error) error {
+	opts := &sql.TxOptions{}
+	if h.serializableQuotas {
+		opts.Isolation = sql.LevelSerializable
+	}
+
+	var err error
+	for attempt := 0; attempt < maxQuotaTxAttempts; attempt++ {
+		var tx *sql.Tx
+		tx, err = h.db.BeginTx(context.Background(), opts)
+		if err != nil {
+			return err
+		}
+
+		err = fn(tx)
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err == nil {
+			return nil
+		}
+
+		tx.Rollback()
+		if !h.serializableQuotas || !isSerializationFailure(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (h *Handlers) lockOrgSettings(tx *sql.Tx, orgid string) (*int, *int, error) {
+	var maxLobbies, maxGames *int
+
+	query := "SELECT maxlobbies, maxgamesperseason FROM organizationsettings WHERE orgid = $1"
+	if !h.serializableQuotas {
+		query += " FOR UPDATE"
+	}
+
+	err := tx.QueryRow(query, orgid).Scan(&maxLobbies, &maxGames)
+	if err == sql.ErrNoRows {
+		return nil, nil, nil
+	}
+	return maxLobbies, maxGames, err
+}
+
+func (h *Handlers) checkGameQuota(tx *sql.Tx, orgid string, seasonid int) error {
+	_, maxGames, err := h.lockOrgSettings(tx, orgid)
+	if err != nil || maxGames == nil {
+		return err
+	}
+
+	var games int
+	query := "SELECT COUNT(*) FROM games WHERE seasonid = $1 AND status <> 'canceled'"
+	if err := tx.QueryRow(query, seasonid).Scan(&games); err != nil {
+		return err
+	}
+	if games >= *maxGames {
+		return &requestError{fiber.StatusConflict, "The season has reached its maximum number of games"}
+	}
+
+	return nil
+}
+
+func (h *Handlers) checkLobbyQuota(tx *sql.Tx, orgid string) error {
+	maxLobbies, _, err := h.lockOrgSettings(tx, orgid)
+	if err != nil || maxLobbies == nil {
+		return err
+	}
+
+	var lobbies int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM lobbies WHERE orgid = $1", orgid).Scan(&lobbies); err != nil {
+		return err
+	}
+	if lobbies >= *maxLobbies {
+		return &requestError{fiber.StatusConflict, "The org has reached its maximum number of lobbies"}
+	}
+
+	return nil
+}
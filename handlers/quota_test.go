@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCreateGameQuotaUnderConcurrency(t *testing.T) {
+	db := testDB(t)
+
+	for _, serializable := range []bool{false, true} {
+		serializable := serializable
+		name := "ForUpdate"
+		if serializable {
+			name = "Serializable"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			const quota = 3
+			org := seedOrg(t, db, 2)
+			if _, err := db.Exec("UPDATE organizationsettings SET maxgamesperseason = $1 WHERE orgid = $2", quota, org.OrgId); err != nil {
+				t.Fatalf("Could not set game quota: %v", err)
+			}
+
+			h := &Handlers{db: db, maxGameParticipants: 8, serializableQuotas: serializable}
+			app := testApp(fiber.MethodPost, "/game", h.CreateGame, org.Owner, org.OrgId)
+			body := fiber.Map{
+				"team1":      []int{org.Members[0]},
+				"team2":      []int{org.Members[1]},
+				"team1score": 10,
+				"team2score": 5,
+			}
+
+			statuses := make([]int, quota+1)
+			var wg sync.WaitGroup
+			for i := range statuses {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					status, _, err := doRequest(app, fiber.MethodPost, "/game", body)
+					if err != nil {
+						t.Errorf("Create game failed: %v", err)
+					}
+					statuses[i] = status
+				}(i)
+			}
+			wg.Wait()
+
+			created := 0
+			for _, status := range statuses {
+				if status == fiber.StatusCreated {
+					created++
+				}
+			}
+			if created != quota {
+				t.Fatalf("Expected exactly %d games to be created, got %d (statuses %v)", quota, created, statuses)
+			}
+
+			var games int
+			if err := db.QueryRow("SELECT COUNT(*) FROM games WHERE seasonid = $1", org.SeasonId).Scan(&games); err != nil {
+				t.Fatalf("Could not count games: %v", err)
+			}
+			if games != quota {
+				t.Fatalf("Expected %d games in the season, found %d", quota, games)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"database/sql"
+	"pedersandvoll/foosballapi/rating"
+
+	"github.com/lib/pq"
+)
+
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func getRatings(q queryer, seasonid int, userids []int) (map[int]int, error) {
+	ratings := make(map[int]int, len(userids))
+	for _, userid := range userids {
+		ratings[userid] = rating.DefaultRating
+	}
+
+	query := "SELECT userid, rating FROM playerratings WHERE seasonid = $1 AND userid = ANY($2)"
+	rows, err := q.Query(query, seasonid, pq.Array(userids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userid, r int
+		if err := rows.Scan(&userid, &r); err != nil {
+			return nil, err
+		}
+		ratings[userid] = r
+	}
+
+	return ratings, rows.Err()
+}
+
+func teamRatings(ratings map[int]int, team []int) []int {
+	values := make([]int, 0, len(team))
+	for _, userid := range team {
+		values = append(values, ratings[userid])
+	}
+	return values
+}
+
+func applyGameRatings(tx *sql.Tx, orgid string, seasonid int, winners, losers []int) (int, error) {
+	players := append(append([]int{}, winners...), losers...)
+
+	queryEnsure := `INSERT INTO playerratings (orgid, seasonid, userid) SELECT $1, $2, unnest($3::int[])
+                    ON CONFLICT (seasonid, userid) DO NOTHING`
+	if _, err := tx.Exec(queryEnsure, orgid, seasonid, pq.Array(players)); err != nil {
+		return 0, err
+	}
+
+	queryLock := "SELECT userid FROM playerratings WHERE seasonid = $1 AND userid = ANY($2) FOR UPDATE"
+	if _, err := tx.Exec(queryLock, seasonid, pq.Array(players)); err != nil {
+		return 0, err
+	}
+
+	ratings, err := getRatings(tx, seasonid, players)
+	if err != nil {
+		return 0, err
+	}
+
+	delta := rating.Delta(
+		rating.TeamRating(teamRatings(ratings, winners)),
+		rating.TeamRating(teamRatings(ratings, losers)),
+	)
+
+	queryUpdate := "UPDATE playerratings SET rating = rating + $1, updated_at = NOW() WHERE seasonid = $2 AND userid = ANY($3)"
+	if _, err := tx.Exec(queryUpdate, delta, seasonid, pq.Array(winners)); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(queryUpdate, -delta, seasonid, pq.Array(losers)); err != nil {
+		return 0, err
+	}
+
+	return delta, nil
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// orgScopePlaceholder marks where a scoped query filters on the org, e.g.
+// "SELECT ... FROM games WHERE orgid = {orgid}". It is replaced with the next
+// positional parameter, bound to the org id.
+const orgScopePlaceholder = "{orgid}"
+
+var errUnscopedQuery = errors.New("query is not scoped to an org")
+
+func scopedQuery(orgid string, query string, args ...interface{}) (string, []interface{}, error) {
+	if orgid == "" {
+		return "", nil, fmt.Errorf("%w: missing org id", errUnscopedQuery)
+	}
+	if !strings.Contains(query, orgScopePlaceholder) {
+		return "", nil, fmt.Errorf("%w: missing %s in %q", errUnscopedQuery, orgScopePlaceholder, query)
+	}
+
+	args = append(args, orgid)
+	return strings.ReplaceAll(query, orgScopePlaceholder, fmt.Sprintf("$%d", len(args))), args, nil
+}
+
+func queryOrg(q queryer, orgid string, query string, args ...interface{}) (*sql.Rows, error) {
+	query, args, err := scopedQuery(orgid, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return q.Query(query, args...)
+}
+
+func scanOrgRow(q queryer, orgid string, query string, args []interface{}, dest ...interface{}) error {
+	query, args, err := scopedQuery(orgid, query, args...)
+	if err != nil {
+		return err
+	}
+	return q.QueryRow(query, args...).Scan(dest...)
+}
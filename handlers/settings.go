@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SettingSource string
+
+const (
+	SettingSourceDefault SettingSource = "default"
+	SettingSourceOrg     SettingSource = "org"
+	SettingSourceApp     SettingSource = "app"
+)
+
+// Column defaults from the organizationsettings migrations.
+const (
+	DefaultMaxLobbies        = 1
+	DefaultMaxGamesPerSeason = 1000
+	DefaultTeam1Color        = "#ffffff"
+	DefaultTeam2Color        = "#000000"
+	DefaultMinRankedGames    = 5
+	DefaultDecayRate         = 0
+	DefaultDecayAfterDays    = 14
+)
+
+type EffectiveSetting struct {
+	Value  interface{}   `json:"value"`
+	Source SettingSource `json:"source"`
+}
+
+func resolveInt(orgValue *int, defaultValue int, appValue *int) EffectiveSetting {
+	switch {
+	case orgValue != nil && *orgValue != defaultValue:
+		return EffectiveSetting{Value: *orgValue, Source: SettingSourceOrg}
+	case orgValue != nil:
+		return EffectiveSetting{Value: *orgValue, Source: SettingSourceDefault}
+	case appValue != nil:
+		return EffectiveSetting{Value: *appValue, Source: SettingSourceApp}
+	default:
+		return EffectiveSetting{Value: defaultValue, Source: SettingSourceDefault}
+	}
+}
+
+func resolveString(orgValue *string, defaultValue string) EffectiveSetting {
+	switch {
+	case orgValue != nil && *orgValue != defaultValue:
+		return EffectiveSetting{Value: *orgValue, Source: SettingSourceOrg}
+	case orgValue != nil:
+		return EffectiveSetting{Value: *orgValue, Source: SettingSourceDefault}
+	default:
+		return EffectiveSetting{Value: defaultValue, Source: SettingSourceDefault}
+	}
+}
+
+func (h *Handlers) effectiveConfig(orgid string) (map[string]EffectiveSetting, error) {
+	settings, err := h.getOrgSettings(orgid)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	lobbyTTL := int(DefaultLobbyTTL.Minutes())
+	maxTeamSize := TeamSize
+	availabilityTTL := int(h.availabilityTTL.Minutes())
+
+	return map[string]EffectiveSetting{
+		"maxlobbies":        resolveInt(settings.MaxLobbies, DefaultMaxLobbies, nil),
+		"maxgamesperseason": resolveInt(settings.MaxGamesPerSeason, DefaultMaxGamesPerSeason, nil),
+		"team1color":        resolveString(settings.Team1Color, DefaultTeam1Color),
+		"team2color":        resolveString(settings.Team2Color, DefaultTeam2Color),
+		"lobbyttl":          resolveInt(settings.LobbyTTL, lobbyTTL, &lobbyTTL),
+		"maxteamsize":       resolveInt(settings.MaxTeamSize, maxTeamSize, &maxTeamSize),
+		"usernamepattern":   resolveString(settings.UsernamePattern, ""),
+		"minrankedgames":    resolveInt(settings.MinRankedGames, DefaultMinRankedGames, nil),
+		"welcomemessage":    resolveString(settings.WelcomeMessage, DefaultWelcomeMessage),
+		"decayrate":         resolveInt(settings.DecayRate, DefaultDecayRate, nil),
+		"decayafterdays":    resolveInt(settings.DecayAfterDays, DefaultDecayAfterDays, nil),
+		"ratingdecay":       {Value: h.ratingDecay, Source: SettingSourceApp},
+		"lazylobbycleanup":  {Value: h.lazyLobbyCleanup, Source: SettingSourceApp},
+		"availabilityttl":   {Value: availabilityTTL, Source: SettingSourceApp},
+		"gamecooldown":      {Value: int(h.gameCooldown.Seconds()), Source: SettingSourceApp},
+	}, nil
+}
+
+func (h *Handlers) GetEffectiveConfig(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	effective, err := h.effectiveConfig(activeOrgStr)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get organization settings",
+		})
+	}
+
+	return c.JSON(effective)
+}
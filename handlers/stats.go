@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+)
+
+type PlayerStats struct {
+	UserId       string `json:"userid"`
+	UserName     string `json:"username"`
+	GamesPlayed  int    `json:"gamesplayed"`
+	Wins         int    `json:"wins"`
+	Losses       int    `json:"losses"`
+	GamesWatched int    `json:"gameswatched"`
+	WinStreak    int    `json:"winstreak"`
+
+	ShutoutsFor     int `json:"shutoutsfor"`
+	ShutoutsAgainst int `json:"shutoutsagainst"`
+
+	DecayActive   bool `json:"decayactive"`
+	RatingDecayed int  `json:"ratingdecayed"`
+}
+
+// getPlayerStats returns sql.ErrNoRows for users outside orgid, so their
+// usernames don't leak to other orgs.
+func (h *Handlers) getPlayerStats(userid string, orgid string) (PlayerStats, error) {
+	var stats PlayerStats
+	queryUser := "SELECT userid, username FROM users WHERE userid = $1 AND activeorg = {orgid}"
+	err := scanOrgRow(h.db, orgid, queryUser, []interface{}{userid}, &stats.UserId, &stats.UserName)
+	if err != nil {
+		return PlayerStats{}, err
+	}
+
+	query := `SELECT COUNT(*), COUNT(*) FILTER (WHERE won)
+              FROM gameparticipants
+              WHERE userid = $1 AND orgid = {orgid} AND status = 'completed'`
+	err = scanOrgRow(h.db, orgid, query, []interface{}{userid}, &stats.GamesPlayed, &stats.Wins)
+	if err != nil {
+		return PlayerStats{}, err
+	}
+	stats.Losses = stats.GamesPlayed - stats.Wins
+
+	queryWatched := `SELECT COUNT(*) FROM gamespectators gs
+                     JOIN games g ON g.gameid = gs.gameid
+                     WHERE gs.userid = $1 AND g.orgid = {orgid} AND g.status = 'completed'`
+	err = scanOrgRow(h.db, orgid, queryWatched, []interface{}{userid}, &stats.GamesWatched)
+	if err != nil {
+		return PlayerStats{}, err
+	}
+
+	queryShutouts := `SELECT COUNT(*) FILTER (WHERE gp.won), COUNT(*) FILTER (WHERE NOT gp.won)
+                      FROM gameparticipants gp
+                      JOIN games g ON g.gameid = gp.gameid
+                      WHERE gp.userid = $1 AND gp.orgid = {orgid} AND gp.status = 'completed' AND g.shutout`
+	err = scanOrgRow(h.db, orgid, queryShutouts, []interface{}{userid}, &stats.ShutoutsFor, &stats.ShutoutsAgainst)
+	if err != nil {
+		return PlayerStats{}, err
+	}
+
+	id, err := strconv.Atoi(stats.UserId)
+	if err != nil {
+		return PlayerStats{}, err
+	}
+	streaks, err := currentStreaks(h.db, orgid, []int{id})
+	if err != nil {
+		return PlayerStats{}, err
+	}
+	stats.WinStreak = streaks[id]
+
+	decay, err := h.decayStatuses(orgid, []int{id})
+	if err != nil {
+		return PlayerStats{}, err
+	}
+	stats.DecayActive = decay[id].Active
+	stats.RatingDecayed = decay[id].Decayed
+
+	return stats, nil
+}
+
+type decayStatus struct {
+	Active  bool
+	Decayed int
+}
+
+// decayStatuses reports, per player, how much their rating has decayed this
+// season and whether it is decaying now. Decay is active once the player has
+// gone longer than the org's decayafterdays without a game this season.
+func (h *Handlers) decayStatuses(orgid string, userids []int) (map[int]decayStatus, error) {
+	query := `SELECT p.userid, COALESCE(pr.decayed, 0),
+              $2 AND COALESCE(s.decayrate, 0) > 0 AND COALESCE(s.decayafterdays, 0) > 0 AND NOT EXISTS (
+                  SELECT 1 FROM gameparticipants gp
+                  WHERE gp.userid = p.userid AND gp.seasonid = o.activeseason AND gp.status = 'completed'
+                  AND gp.createdat > NOW() - s.decayafterdays * INTERVAL '1 day'
+              )
+              FROM organizations o
+              CROSS JOIN unnest($1::int[]) AS p(userid)
+              LEFT JOIN organizationsettings s ON s.orgid = o.orgid
+              LEFT JOIN playerratings pr ON pr.seasonid = o.activeseason AND pr.userid = p.userid
+              WHERE o.orgid = {orgid}`
+	rows, err := queryOrg(h.db, orgid, query, pq.Array(userids), h.ratingDecay)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statuses := make(map[int]decayStatus, len(userids))
+	for rows.Next() {
+		var userid int
+		var status decayStatus
+		if err := rows.Scan(&userid, &status.Decayed, &status.Active); err != nil {
+			return nil, err
+		}
+		statuses[userid] = status
+	}
+
+	return statuses, rows.Err()
+}
+
+func (h *Handlers) GetPlayerStats(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	userID := c.Query("userid", userIdFromToken(c))
+	if _, err := strconv.Atoi(userID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid userid",
+		})
+	}
+
+	stats, err := h.getPlayerStats(userID, activeOrgStr)
+	if err == sql.ErrNoRows {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User is not a member of the org",
+		})
+	} else if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	return c.JSON(stats)
+}
+
+const MaxBulkStatsPlayers = 50
+
+func parseIdList(list string) ([]int, error) {
+	var ids []int
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (h *Handlers) GetBulkPlayerStats(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	userids, err := parseIdList(c.Query("userids"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userids must be a comma separated list of ids",
+		})
+	}
+	if len(userids) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "At least one userid is required",
+		})
+	}
+	if len(userids) > MaxBulkStatsPlayers {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("At most %d userids can be requested at once", MaxBulkStatsPlayers),
+		})
+	}
+
+	query := `SELECT u.userid, u.username,
+                  COUNT(gp.gameid), COUNT(gp.gameid) FILTER (WHERE gp.won), COALESCE(MAX(w.watched), 0),
+                  COUNT(gp.gameid) FILTER (WHERE gp.won AND g.shutout), COUNT(gp.gameid) FILTER (WHERE NOT gp.won AND g.shutout)
+              FROM users u
+              LEFT JOIN (gameparticipants gp JOIN games g ON g.gameid = gp.gameid)
+                  ON gp.userid = u.userid AND gp.orgid = {orgid} AND gp.status = 'completed'
+              LEFT JOIN (
+                  SELECT gs.userid, COUNT(*) AS watched
+                  FROM gamespectators gs
+                  JOIN games g ON g.gameid = gs.gameid
+                  WHERE g.orgid = {orgid} AND g.status = 'completed' AND gs.userid = ANY($1)
+                  GROUP BY gs.userid
+              ) w ON w.userid = u.userid
+              WHERE u.activeorg = {orgid} AND u.userid = ANY($1)
+              GROUP BY u.userid, u.username
+              ORDER BY u.userid`
+	rows, err := queryOrg(h.db, activeOrgStr, query, pq.Array(userids))
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
+	}
+	defer rows.Close()
+
+	var stats []PlayerStats
+	for rows.Next() {
+		var s PlayerStats
+		err := rows.Scan(&s.UserId, &s.UserName, &s.GamesPlayed, &s.Wins, &s.GamesWatched, &s.ShutoutsFor, &s.ShutoutsAgainst)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to scan row"})
+		}
+		s.Losses = s.GamesPlayed - s.Wins
+		stats = append(stats, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Error iterating over rows"})
+	}
+
+	streaks, err := currentStreaks(h.db, activeOrgStr, userids)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
+	}
+	decay, err := h.decayStatuses(activeOrgStr, userids)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
+	}
+	for i := range stats {
+		id, _ := strconv.Atoi(stats[i].UserId)
+		stats[i].WinStreak = streaks[id]
+		stats[i].DecayActive = decay[id].Active
+		stats[i].RatingDecayed = decay[id].Decayed
+	}
+
+	return c.JSON(stats)
+}
+
+type ColorStats struct {
+	Color   string  `json:"color"`
+	Games   int     `json:"games"`
+	Wins    int     `json:"wins"`
+	WinRate float64 `json:"winrate"`
+}
+
+type OrgStats struct {
+	GamesPlayed int          `json:"gamesplayed"`
+	Players     int          `json:"players"`
+	Colors      []ColorStats `json:"colors"`
+}
+
+func (h *Handlers) GetOrgStats(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	stats := OrgStats{Colors: []ColorStats{}}
+
+	query := `SELECT COUNT(DISTINCT gameid), COUNT(DISTINCT userid)
+              FROM gameparticipants WHERE orgid = {orgid} AND status = 'completed'`
+	if err := scanOrgRow(h.db, activeOrgStr, query, nil, &stats.GamesPlayed, &stats.Players); err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	// Games recorded without colors are left out of the color stats.
+	queryColors := `SELECT LOWER(color), COUNT(*), COUNT(*) FILTER (WHERE won) FROM (
+                        SELECT team1_color AS color, team1_score > team2_score AS won FROM games
+                        WHERE orgid = {orgid} AND status = 'completed' AND team1_color IS NOT NULL
+                        UNION ALL
+                        SELECT team2_color AS color, team2_score > team1_score AS won FROM games
+                        WHERE orgid = {orgid} AND status = 'completed' AND team2_color IS NOT NULL
+                    ) sides
+                    GROUP BY LOWER(color)
+                    ORDER BY LOWER(color)`
+	rows, err := queryOrg(h.db, activeOrgStr, queryColors)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var color ColorStats
+		if err := rows.Scan(&color.Color, &color.Games, &color.Wins); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to scan color stats",
+			})
+		}
+		color.WinRate = float64(color.Wins) / float64(color.Games)
+		stats.Colors = append(stats.Colors, color)
+	}
+	if err := rows.Err(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Error iterating color stats",
+		})
+	}
+
+	return c.JSON(stats)
+}
+
+type MemberInfo struct {
+	UserId      int         `json:"userid"`
+	UserName    string      `json:"username"`
+	Role        string      `json:"role"`
+	Provisional bool        `json:"provisional"`
+	JoinedAt    *time.Time  `json:"joinedat"`
+	Stats       PlayerStats `json:"stats"`
+}
+
+func (h *Handlers) GetMemberInfo(c *fiber.Ctx) error {
+	activeOrgStr, status, msg := h.requireOrgOwner(c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+
+	userID, err := strconv.Atoi(c.Query("userid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid userid",
+		})
+	}
+
+	var info MemberInfo
+	var isOwner bool
+	query := `SELECT u.userid, u.username, u.provisional, u.joined_at, COALESCE(s.orgowner = u.userid, FALSE)
+              FROM users u
+              LEFT JOIN organizationsettings s ON s.orgid = u.activeorg
+              WHERE u.userid = $1 AND u.activeorg = {orgid}`
+	err = scanOrgRow(h.db, activeOrgStr, query, []interface{}{userID},
+		&info.UserId, &info.UserName, &info.Provisional, &info.JoinedAt, &isOwner)
+	if err == sql.ErrNoRows {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User is not a member of the org",
+		})
+	} else if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	info.Role = RoleMember
+	if isOwner {
+		info.Role = RoleOwner
+	}
+
+	info.Stats, err = h.getPlayerStats(strconv.Itoa(userID), activeOrgStr)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	return c.JSON(info)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+)
+
+const DefaultHotStreakLength = 3
+
+// currentStreaksQuery counts each player's wins since their last loss. Only
+// completed games count, so canceled games neither extend nor break a streak.
+const currentStreaksQuery = `WITH ordered AS (
+                                 SELECT userid, won,
+                                        ROW_NUMBER() OVER (PARTITION BY userid ORDER BY createdat DESC, gameid DESC) AS rn
+                                 FROM gameparticipants
+                                 WHERE orgid = {orgid} AND status = 'completed' AND userid = ANY($1)
+                             )
+                             SELECT userid, COALESCE(MIN(rn) FILTER (WHERE NOT won) - 1, COUNT(*)) AS streak
+                             FROM ordered
+                             GROUP BY userid`
+
+func currentStreaks(q queryer, orgid string, userids []int) (map[int]int, error) {
+	streaks := make(map[int]int, len(userids))
+
+	rows, err := queryOrg(q, orgid, currentStreaksQuery, pq.Array(userids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userid, streak int
+		if err := rows.Scan(&userid, &streak); err != nil {
+			return nil, err
+		}
+		streaks[userid] = streak
+	}
+
+	return streaks, rows.Err()
+}
+
+type StreakMilestone struct {
+	UserId int `json:"userid"`
+	Streak int `json:"streak"`
+}
+
+// recordStreakMilestones records a milestone for every winner whose streak
+// just reached a multiple of the configured milestone interval, and returns
+// them so the game's response can announce them.
+func (h *Handlers) recordStreakMilestones(tx *sql.Tx, orgid string, gameid int, winners []int) ([]StreakMilestone, error) {
+	milestones := []StreakMilestone{}
+	if h.streakMilestone <= 0 {
+		return milestones, nil
+	}
+
+	streaks, err := currentStreaks(tx, orgid, winners)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "INSERT INTO streakmilestones (orgid, userid, gameid, streak) VALUES ($1, $2, $3, $4)"
+	for _, userid := range winners {
+		streak := streaks[userid]
+		if streak == 0 || streak%h.streakMilestone != 0 {
+			continue
+		}
+		if _, err := tx.Exec(query, orgid, userid, gameid, streak); err != nil {
+			return nil, err
+		}
+		log.Printf("Player %d in org %s reached a %d game win streak", userid, orgid, streak)
+		milestones = append(milestones, StreakMilestone{UserId: userid, Streak: streak})
+	}
+
+	return milestones, nil
+}
+
+type HotStreak struct {
+	UserId   int    `json:"userid"`
+	UserName string `json:"username"`
+	Streak   int    `json:"streak"`
+}
+
+func (h *Handlers) GetHotStreaks(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	minStreak := c.QueryInt("min", DefaultHotStreakLength)
+	if minStreak < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Min must be at least 1",
+		})
+	}
+
+	query := `WITH ordered AS (
+                  SELECT gp.userid, gp.won,
+                         ROW_NUMBER() OVER (PARTITION BY gp.userid ORDER BY gp.createdat DESC, gp.gameid DESC) AS rn
+                  FROM gameparticipants gp
+                  JOIN users u ON u.userid = gp.userid AND u.activeorg = gp.orgid
+                  WHERE gp.orgid = {orgid} AND gp.status = 'completed'
+              ), streaks AS (
+                  SELECT userid, COALESCE(MIN(rn) FILTER (WHERE NOT won) - 1, COUNT(*)) AS streak
+                  FROM ordered
+                  GROUP BY userid
+              )
+              SELECT s.userid, u.username, s.streak
+              FROM streaks s
+              JOIN users u ON u.userid = s.userid
+              WHERE s.streak >= $1
+              ORDER BY s.streak DESC, u.username`
+	rows, err := queryOrg(h.db, activeOrgStr, query, minStreak)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	defer rows.Close()
+
+	streaks := []HotStreak{}
+	for rows.Next() {
+		var streak HotStreak
+		if err := rows.Scan(&streak.UserId, &streak.UserName, &streak.Streak); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to scan streak",
+			})
+		}
+		streaks = append(streaks, streak)
+	}
+	if err := rows.Err(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Error iterating streaks",
+		})
+	}
+
+	return c.JSON(streaks)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"database/sql"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// requireUnsuspendedOrg guards the handlers that create games and lobbies.
+// Reads keep working while an org is suspended. The loaded org is returned
+// so callers don't have to look it up again.
+func (h *Handlers) requireUnsuspendedOrg(c *fiber.Ctx, orgid string) (OrgDetails, int, string) {
+	org, err := h.GetOrgDetails(c, orgid)
+	if err == sql.ErrNoRows {
+		return OrgDetails{}, fiber.StatusUnauthorized, "Organization does not exist"
+	} else if err != nil {
+		return OrgDetails{}, fiber.StatusInternalServerError, "Database error"
+	}
+	if org.Suspended {
+		return OrgDetails{}, fiber.StatusLocked, "Organization is suspended"
+	}
+	return org, 0, ""
+}
+
+func (h *Handlers) SuspendOrganization(c *fiber.Ctx) error {
+	activeOrgStr, status, msg := h.requireOrgOwner(c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+
+	result, err := h.db.Exec("UPDATE organizations SET suspended_at = NOW() WHERE orgid = $1 AND suspended_at IS NULL", activeOrgStr)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to suspend organization",
+		})
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Organization is already suspended",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Organization suspended",
+	})
+}
+
+func (h *Handlers) ResumeOrganization(c *fiber.Ctx) error {
+	activeOrgStr, status, msg := h.requireOrgOwner(c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+
+	result, err := h.db.Exec("UPDATE organizations SET suspended_at = NULL WHERE orgid = $1 AND suspended_at IS NOT NULL", activeOrgStr)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to resume organization",
+		})
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Organization is not suspended",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Organization resumed",
+	})
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSuspendedOrgIsMarked(t *testing.T) {
+	db := testDB(t)
+	org := seedOrg(t, db, 0)
+	h := &Handlers{db: db}
+
+	suspend := testApp(fiber.MethodPost, "/suspend/org", h.SuspendOrganization, org.Owner, org.OrgId)
+	if status, body := doJSON(t, suspend, fiber.MethodPost, "/suspend/org", nil); status != fiber.StatusOK {
+		t.Fatalf("Expected the org to be suspended, got %d: %v", status, body)
+	}
+
+	info := testApp(fiber.MethodGet, "/org", h.GetOrg, org.Owner, org.OrgId)
+	status, body := doJSON(t, info, fiber.MethodGet, "/org", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", status, body)
+	}
+	if body["suspended"] != true {
+		t.Fatalf("Expected the org info to be marked suspended, got %v", body)
+	}
+	if _, ok := body["orgsecret"]; ok {
+		t.Fatalf("Org info should not include the secret")
+	}
+
+	list := testApp(fiber.MethodGet, "/orgs", h.GetMyOrgs, org.Owner, org.OrgId)
+	orgs := doJSONList(t, list, "/orgs")
+	found := false
+	for _, item := range orgs {
+		if fmt.Sprint(item["orgid"]) == org.OrgId {
+			found = true
+			if item["suspended"] != true {
+				t.Fatalf("Expected the org listing to mark the org suspended, got %v", item)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("Org missing from the listing: %v", orgs)
+	}
+}
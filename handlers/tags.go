@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+)
+
+const MaxTagNameLength = 50
+
+type Tag struct {
+	TagId int    `json:"tagid"`
+	Name  string `json:"name"`
+	Rated bool   `json:"rated"`
+}
+
+type CreateTagBody struct {
+	Name  string `json:"name"`
+	Rated *bool  `json:"rated"`
+}
+
+func (h *Handlers) CreateTag(c *fiber.Ctx) error {
+	activeOrgStr, status, msg := h.requireOrgOwner(c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+
+	var body CreateTagBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" || len(body.Name) > MaxTagNameLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Tag name must be between 1 and 50 characters",
+		})
+	}
+
+	rated := true
+	if body.Rated != nil {
+		rated = *body.Rated
+	}
+
+	var tagId int
+	query := "INSERT INTO gametags (orgid, name, rated) VALUES ($1, $2, $3) RETURNING tagid"
+	if err := h.db.QueryRow(query, activeOrgStr, body.Name, rated).Scan(&tagId); err != nil {
+		if strings.Contains(err.Error(), "unique constraint") {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Tag already exists",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create tag",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Tag created successfully",
+		"tagid":   tagId,
+	})
+}
+
+func (h *Handlers) GetTags(c *fiber.Ctx) error {
+	activeOrgStr, ok := activeOrgFromToken(c)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "User not part of any org",
+		})
+	}
+
+	rows, err := queryOrg(h.db, activeOrgStr, "SELECT tagid, name, rated FROM gametags WHERE orgid = {orgid} ORDER BY name")
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	defer rows.Close()
+
+	tags := []Tag{}
+	for rows.Next() {
+		var tag Tag
+		if err := rows.Scan(&tag.TagId, &tag.Name, &tag.Rated); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to scan tag",
+			})
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Error iterating tags",
+		})
+	}
+
+	return c.JSON(tags)
+}
+
+// resolveTags looks up the org's tags by name, case-insensitively. It
+// returns nil tags without an error when any of the names is not a tag of
+// the org.
+func (h *Handlers) resolveTags(orgid string, names []string) ([]Tag, error) {
+	lowered := make([]string, 0, len(names))
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !seen[name] {
+			seen[name] = true
+			lowered = append(lowered, name)
+		}
+	}
+
+	query := "SELECT tagid, name, rated FROM gametags WHERE orgid = {orgid} AND LOWER(name) = ANY($1)"
+	rows, err := queryOrg(h.db, orgid, query, pq.Array(lowered))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []Tag
+	for rows.Next() {
+		var tag Tag
+		if err := rows.Scan(&tag.TagId, &tag.Name, &tag.Rated); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(tags) != len(lowered) {
+		return nil, nil
+	}
+	return tags, nil
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"pedersandvoll/foosballapi/version"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// schemaVersion reads the version golang-migrate recorded for the database.
+// It is nil when the migrations table can't be read.
+func (h *Handlers) schemaVersion() (*int64, bool) {
+	var schema int64
+	var dirty bool
+	if err := h.db.QueryRow("SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&schema, &dirty); err != nil {
+		return nil, false
+	}
+	return &schema, dirty
+}
+
+func (h *Handlers) GetVersion(c *fiber.Ctx) error {
+	schema, dirty := h.schemaVersion()
+
+	return c.JSON(fiber.Map{
+		"version":       version.Version,
+		"commit":        version.Commit,
+		"buildtime":     version.BuildTime,
+		"schemaversion": schema,
+		"schemadirty":   dirty,
+	})
+}
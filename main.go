@@ -5,7 +5,9 @@ import (
 	"pedersandvoll/foosballapi/cleanup"
 	"pedersandvoll/foosballapi/config"
 	"pedersandvoll/foosballapi/handlers"
+	"pedersandvoll/foosballapi/middleware"
 	"pedersandvoll/foosballapi/routes"
+	"pedersandvoll/foosballapi/version"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -27,12 +29,31 @@ func main() {
 
 	app := fiber.New()
 
-	h := handlers.NewHandlers(db, dbConfig.JWTSecret)
+	app.Use(middleware.SecureHeaders(middleware.SecurityConfig{
+		HSTS:           dbConfig.HSTS,
+		HSTSMaxAge:     dbConfig.HSTSMaxAge,
+		NoSniff:        dbConfig.NoSniff,
+		FrameOptions:   dbConfig.FrameOptions,
+		ReferrerPolicy: dbConfig.ReferrerPolicy,
+		ForceHTTPS:     dbConfig.ForceHTTPS,
+		TrustProxy:     dbConfig.TrustProxy,
+	}))
+
+	h := handlers.NewHandlers(db, dbConfig)
+
+	if dbConfig.LobbySweeper {
+		service := cleanup.NewLobbyCleanupService(db, 1*time.Minute, handlers.DefaultLobbyTTL)
+		service.Start()
+	}
 
-	service := cleanup.NewLobbyCleanupService(db, 1*time.Minute, 30*time.Minute)
-	service.Start()
+	if dbConfig.RatingDecay {
+		service := cleanup.NewRatingDecayService(db, 1*time.Hour)
+		service.Start()
+	}
 
 	routes.Routes(app, h)
 
+	log.Printf("Starting foosball api %s (commit %s, built %s)", version.Version, version.Commit, version.BuildTime)
+
 	app.Listen(":3000")
 }
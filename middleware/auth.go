@@ -34,6 +34,13 @@ func AuthRequired(jwtSecret []byte) fiber.Handler {
 			} else {
 				c.Locals("activeorg", nil)
 			}
+			if role, ok := claims["role"].(string); ok {
+				c.Locals("role", role)
+			}
+			if tokenType, ok := claims["type"].(string); ok {
+				c.Locals("tokentype", tokenType)
+				c.Locals("kioskid", claims["kioskid"])
+			}
 			c.Locals("user", token)
 		}
 
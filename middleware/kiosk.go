@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+const TokenTypeKiosk = "kiosk"
+
+// KioskAccess limits kiosk tokens to the allowed "METHOD /path" routes and
+// rejects kiosk tokens that have expired or been revoked.
+func KioskAccess(isActive func(kioskid string) (bool, error), allowed map[string]bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Locals("tokentype") != TokenTypeKiosk {
+			return c.Next()
+		}
+
+		kioskid, _ := c.Locals("kioskid").(string)
+		active, err := isActive(kioskid)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to verify kiosk token",
+			})
+		}
+		if !active {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Kiosk token has been revoked",
+			})
+		}
+
+		if !allowed[c.Method()+" "+c.Path()] {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Kiosk tokens can only record games and look up members",
+			})
+		}
+
+		return c.Next()
+	}
+}
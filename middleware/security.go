@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SecurityConfig struct {
+	HSTS           bool
+	HSTSMaxAge     int
+	NoSniff        bool
+	FrameOptions   string
+	ReferrerPolicy string
+	ForceHTTPS     bool
+	TrustProxy     bool
+}
+
+func isSecure(c *fiber.Ctx, trustProxy bool) bool {
+	if c.Context().IsTLS() {
+		return true
+	}
+	if trustProxy {
+		proto, _, _ := strings.Cut(c.Get(fiber.HeaderXForwardedProto), ",")
+		return strings.EqualFold(strings.TrimSpace(proto), "https")
+	}
+	return false
+}
+
+func headerEnabled(value string) bool {
+	return value != "" && !strings.EqualFold(value, "off")
+}
+
+func SecureHeaders(config SecurityConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		secure := isSecure(c, config.TrustProxy)
+
+		// 308 rather than 301, so clients repeat a POST as a POST with its body.
+		if config.ForceHTTPS && !secure {
+			return c.Redirect("https://"+c.Hostname()+c.OriginalURL(), fiber.StatusPermanentRedirect)
+		}
+
+		if config.HSTS && secure {
+			c.Set(fiber.HeaderStrictTransportSecurity, "max-age="+strconv.Itoa(config.HSTSMaxAge)+"; includeSubDomains")
+		}
+		if config.NoSniff {
+			c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+		}
+		if headerEnabled(config.FrameOptions) {
+			c.Set(fiber.HeaderXFrameOptions, config.FrameOptions)
+		}
+		if headerEnabled(config.ReferrerPolicy) {
+			c.Set(fiber.HeaderReferrerPolicy, config.ReferrerPolicy)
+		}
+
+		return c.Next()
+	}
+}
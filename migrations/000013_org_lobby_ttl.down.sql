@@ -0,0 +1,2 @@
+ALTER TABLE organizationsettings
+DROP COLUMN lobbyttl;
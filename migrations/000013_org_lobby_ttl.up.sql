@@ -0,0 +1,2 @@
+ALTER TABLE organizationsettings
+ADD COLUMN lobbyttl INT DEFAULT 30;
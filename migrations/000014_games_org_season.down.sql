@@ -0,0 +1,34 @@
+DROP VIEW IF EXISTS gameparticipants;
+
+DROP INDEX IF EXISTS idx_games_seasonid;
+DROP INDEX IF EXISTS idx_games_orgid;
+
+ALTER TABLE games
+DROP CONSTRAINT fk_orgid,
+DROP CONSTRAINT fk_seasonid,
+DROP CONSTRAINT fk_lobbyid,
+DROP CONSTRAINT fk_team1_player1,
+DROP CONSTRAINT fk_team1_player2,
+DROP CONSTRAINT fk_team2_player1,
+DROP CONSTRAINT fk_team2_player2;
+
+-- Games played outside a lobby have no lobby players to point back to.
+DELETE FROM games WHERE lobbyid IS NULL;
+
+UPDATE games g
+SET team1_player1 = (SELECT playerid FROM lobbyplayers WHERE lobbyid = g.lobbyid AND userid = g.team1_player1),
+    team1_player2 = (SELECT playerid FROM lobbyplayers WHERE lobbyid = g.lobbyid AND userid = g.team1_player2),
+    team2_player1 = (SELECT playerid FROM lobbyplayers WHERE lobbyid = g.lobbyid AND userid = g.team2_player1),
+    team2_player2 = (SELECT playerid FROM lobbyplayers WHERE lobbyid = g.lobbyid AND userid = g.team2_player2);
+
+ALTER TABLE games
+DROP COLUMN orgid,
+DROP COLUMN seasonid,
+ALTER COLUMN lobbyid SET NOT NULL;
+
+ALTER TABLE games
+ADD CONSTRAINT fk_lobbyid FOREIGN KEY (lobbyid) REFERENCES lobbies(lobbyid) ON DELETE CASCADE,
+ADD CONSTRAINT fk_team1_player1 FOREIGN KEY (team1_player1) REFERENCES lobbyplayers(playerid) ON DELETE RESTRICT,
+ADD CONSTRAINT fk_team1_player2 FOREIGN KEY (team1_player2) REFERENCES lobbyplayers(playerid) ON DELETE RESTRICT,
+ADD CONSTRAINT fk_team2_player1 FOREIGN KEY (team2_player1) REFERENCES lobbyplayers(playerid) ON DELETE RESTRICT,
+ADD CONSTRAINT fk_team2_player2 FOREIGN KEY (team2_player2) REFERENCES lobbyplayers(playerid) ON DELETE RESTRICT;
@@ -0,0 +1,48 @@
+ALTER TABLE games
+DROP CONSTRAINT fk_lobbyid,
+DROP CONSTRAINT fk_team1_player1,
+DROP CONSTRAINT fk_team1_player2,
+DROP CONSTRAINT fk_team2_player1,
+DROP CONSTRAINT fk_team2_player2;
+
+ALTER TABLE games
+ADD COLUMN orgid INT,
+ADD COLUMN seasonid INT;
+
+-- Every game so far was played in a lobby, so take the org and season from
+-- there, and swap the lobby player ids for the user ids they stand for.
+UPDATE games g
+SET orgid = l.orgid,
+    seasonid = l.seasonid,
+    team1_player1 = (SELECT userid FROM lobbyplayers WHERE playerid = g.team1_player1),
+    team1_player2 = (SELECT userid FROM lobbyplayers WHERE playerid = g.team1_player2),
+    team2_player1 = (SELECT userid FROM lobbyplayers WHERE playerid = g.team2_player1),
+    team2_player2 = (SELECT userid FROM lobbyplayers WHERE playerid = g.team2_player2)
+FROM lobbies l
+WHERE l.lobbyid = g.lobbyid;
+
+ALTER TABLE games
+ALTER COLUMN orgid SET NOT NULL,
+ALTER COLUMN seasonid SET NOT NULL,
+ALTER COLUMN lobbyid DROP NOT NULL;
+
+ALTER TABLE games
+ADD CONSTRAINT fk_orgid FOREIGN KEY (orgid) REFERENCES organizations(orgid) ON DELETE CASCADE,
+ADD CONSTRAINT fk_seasonid FOREIGN KEY (seasonid) REFERENCES seasons(seasonid) ON DELETE CASCADE,
+ADD CONSTRAINT fk_lobbyid FOREIGN KEY (lobbyid) REFERENCES lobbies(lobbyid) ON DELETE SET NULL,
+ADD CONSTRAINT fk_team1_player1 FOREIGN KEY (team1_player1) REFERENCES users(userid) ON DELETE CASCADE,
+ADD CONSTRAINT fk_team1_player2 FOREIGN KEY (team1_player2) REFERENCES users(userid) ON DELETE CASCADE,
+ADD CONSTRAINT fk_team2_player1 FOREIGN KEY (team2_player1) REFERENCES users(userid) ON DELETE CASCADE,
+ADD CONSTRAINT fk_team2_player2 FOREIGN KEY (team2_player2) REFERENCES users(userid) ON DELETE CASCADE;
+
+CREATE INDEX idx_games_orgid ON games(orgid);
+CREATE INDEX idx_games_seasonid ON games(seasonid);
+
+CREATE VIEW gameparticipants AS
+SELECT gameid, orgid, seasonid, status, createdat, team1_player1 AS userid, 1 AS team, team1_score > team2_score AS won FROM games
+UNION ALL
+SELECT gameid, orgid, seasonid, status, createdat, team1_player2 AS userid, 1 AS team, team1_score > team2_score AS won FROM games
+UNION ALL
+SELECT gameid, orgid, seasonid, status, createdat, team2_player1 AS userid, 2 AS team, team2_score > team1_score AS won FROM games
+UNION ALL
+SELECT gameid, orgid, seasonid, status, createdat, team2_player2 AS userid, 2 AS team, team2_score > team1_score AS won FROM games;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_gamespectators_userid;
+DROP TABLE IF EXISTS gamespectators;
@@ -0,0 +1,10 @@
+CREATE TABLE gamespectators (
+    gameid INT NOT NULL,
+    userid INT NOT NULL,
+
+    CONSTRAINT pk_gamespectators PRIMARY KEY (gameid, userid),
+    CONSTRAINT fk_gameid FOREIGN KEY (gameid) REFERENCES games(gameid) ON DELETE CASCADE,
+    CONSTRAINT fk_userid FOREIGN KEY (userid) REFERENCES users(userid) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_gamespectators_userid ON gamespectators(userid);
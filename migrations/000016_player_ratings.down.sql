@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_playerratings_userid;
+DROP INDEX IF EXISTS idx_playerratings_orgid;
+DROP TABLE IF EXISTS playerratings;
@@ -0,0 +1,15 @@
+CREATE TABLE playerratings (
+    orgid INT NOT NULL,
+    seasonid INT NOT NULL,
+    userid INT NOT NULL,
+    rating INT NOT NULL DEFAULT 1000,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT pk_playerratings PRIMARY KEY (seasonid, userid),
+    CONSTRAINT fk_orgid FOREIGN KEY (orgid) REFERENCES organizations(orgid) ON DELETE CASCADE,
+    CONSTRAINT fk_seasonid FOREIGN KEY (seasonid) REFERENCES seasons(seasonid) ON DELETE CASCADE,
+    CONSTRAINT fk_userid FOREIGN KEY (userid) REFERENCES users(userid) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_playerratings_orgid ON playerratings(orgid);
+CREATE INDEX idx_playerratings_userid ON playerratings(userid);
@@ -0,0 +1,2 @@
+ALTER TABLE seasons
+DROP COLUMN ended_at;
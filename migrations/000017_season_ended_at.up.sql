@@ -0,0 +1,2 @@
+ALTER TABLE seasons
+ADD COLUMN ended_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_playeravailability_orgid;
+DROP TABLE IF EXISTS playeravailability;
+DROP TYPE IF EXISTS availability_status;
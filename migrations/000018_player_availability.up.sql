@@ -0,0 +1,15 @@
+CREATE TYPE availability_status AS ENUM ('free', 'busy');
+
+CREATE TABLE playeravailability (
+    userid INT NOT NULL,
+    orgid INT NOT NULL,
+    status availability_status NOT NULL DEFAULT 'free',
+    expires_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT pk_playeravailability PRIMARY KEY (userid, orgid),
+    CONSTRAINT fk_userid FOREIGN KEY (userid) REFERENCES users(userid) ON DELETE CASCADE,
+    CONSTRAINT fk_orgid FOREIGN KEY (orgid) REFERENCES organizations(orgid) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_playeravailability_orgid ON playeravailability(orgid);
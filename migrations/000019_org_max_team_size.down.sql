@@ -0,0 +1,15 @@
+CREATE OR REPLACE VIEW gameparticipants AS
+SELECT gameid, orgid, seasonid, status, createdat, team1_player1 AS userid, 1 AS team, team1_score > team2_score AS won FROM games
+UNION ALL
+SELECT gameid, orgid, seasonid, status, createdat, team1_player2 AS userid, 1 AS team, team1_score > team2_score AS won FROM games
+UNION ALL
+SELECT gameid, orgid, seasonid, status, createdat, team2_player1 AS userid, 2 AS team, team2_score > team1_score AS won FROM games
+UNION ALL
+SELECT gameid, orgid, seasonid, status, createdat, team2_player2 AS userid, 2 AS team, team2_score > team1_score AS won FROM games;
+
+ALTER TABLE games
+ALTER COLUMN team1_player2 SET NOT NULL,
+ALTER COLUMN team2_player2 SET NOT NULL;
+
+ALTER TABLE organizationsettings
+DROP COLUMN maxteamsize;
@@ -0,0 +1,5 @@
+ALTER TABLE games
+DROP CONSTRAINT IF EXISTS fk_reported_by;
+
+ALTER TABLE games
+DROP COLUMN reported_by;
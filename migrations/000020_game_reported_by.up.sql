@@ -0,0 +1,8 @@
+ALTER TABLE games
+ADD COLUMN reported_by INT;
+
+ALTER TABLE games
+ADD CONSTRAINT fk_reported_by
+FOREIGN KEY (reported_by)
+REFERENCES users(userid)
+ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE organizationsettings
+ADD COLUMN usernamepattern VARCHAR(255);
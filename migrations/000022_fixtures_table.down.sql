@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_fixtures_scheduled_at;
+DROP INDEX IF EXISTS idx_fixtures_orgid;
+DROP TABLE IF EXISTS fixtures;
@@ -0,0 +1,25 @@
+CREATE TABLE fixtures (
+    fixtureid SERIAL PRIMARY KEY,
+    orgid INT NOT NULL,
+    seasonid INT NOT NULL,
+    team1_player1 INT NOT NULL,
+    team1_player2 INT,
+    team2_player1 INT NOT NULL,
+    team2_player2 INT,
+    scheduled_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    createdby INT NOT NULL,
+    gameid INT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_orgid FOREIGN KEY (orgid) REFERENCES organizations(orgid) ON DELETE CASCADE,
+    CONSTRAINT fk_seasonid FOREIGN KEY (seasonid) REFERENCES seasons(seasonid) ON DELETE CASCADE,
+    CONSTRAINT fk_team1_player1 FOREIGN KEY (team1_player1) REFERENCES users(userid) ON DELETE CASCADE,
+    CONSTRAINT fk_team1_player2 FOREIGN KEY (team1_player2) REFERENCES users(userid) ON DELETE CASCADE,
+    CONSTRAINT fk_team2_player1 FOREIGN KEY (team2_player1) REFERENCES users(userid) ON DELETE CASCADE,
+    CONSTRAINT fk_team2_player2 FOREIGN KEY (team2_player2) REFERENCES users(userid) ON DELETE CASCADE,
+    CONSTRAINT fk_createdby FOREIGN KEY (createdby) REFERENCES users(userid) ON DELETE CASCADE,
+    CONSTRAINT fk_gameid FOREIGN KEY (gameid) REFERENCES games(gameid) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_fixtures_orgid ON fixtures(orgid);
+CREATE INDEX idx_fixtures_scheduled_at ON fixtures(scheduled_at);
@@ -0,0 +1,3 @@
+ALTER TABLE seasons DROP CONSTRAINT IF EXISTS unique_season_name_per_org;
+
+ALTER TABLE seasons ADD CONSTRAINT seasons_name_key UNIQUE (name);
@@ -0,0 +1,3 @@
+ALTER TABLE seasons DROP CONSTRAINT IF EXISTS seasons_name_key;
+
+ALTER TABLE seasons ADD CONSTRAINT unique_season_name_per_org UNIQUE (orgid, name);
@@ -0,0 +1,2 @@
+ALTER TABLE organizationsettings
+ADD COLUMN minrankedgames INT DEFAULT 5;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_kiosktokens_orgid;
+DROP TABLE IF EXISTS kiosktokens;
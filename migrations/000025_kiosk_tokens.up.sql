@@ -0,0 +1,13 @@
+CREATE TABLE kiosktokens (
+    kioskid SERIAL PRIMARY KEY,
+    orgid INT NOT NULL,
+    createdby INT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_orgid FOREIGN KEY (orgid) REFERENCES organizations(orgid) ON DELETE CASCADE,
+    CONSTRAINT fk_createdby FOREIGN KEY (createdby) REFERENCES users(userid) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_kiosktokens_orgid ON kiosktokens(orgid);
@@ -0,0 +1,2 @@
+ALTER TABLE organizations
+DROP COLUMN deleted_at;
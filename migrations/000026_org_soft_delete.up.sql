@@ -0,0 +1,2 @@
+ALTER TABLE organizations
+ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,9 @@
+DELETE FROM users WHERE provisional;
+
+DROP INDEX IF EXISTS unique_provisional_name_per_org;
+DROP INDEX IF EXISTS unique_username;
+
+ALTER TABLE users
+ADD CONSTRAINT users_username_key UNIQUE (username),
+ALTER COLUMN password SET NOT NULL,
+DROP COLUMN provisional;
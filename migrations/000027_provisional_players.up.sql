@@ -0,0 +1,7 @@
+ALTER TABLE users
+ADD COLUMN provisional BOOLEAN NOT NULL DEFAULT FALSE,
+ALTER COLUMN password DROP NOT NULL,
+DROP CONSTRAINT IF EXISTS users_username_key;
+
+CREATE UNIQUE INDEX unique_username ON users(username) WHERE NOT provisional;
+CREATE UNIQUE INDEX unique_provisional_name_per_org ON users(activeorg, LOWER(username)) WHERE provisional;
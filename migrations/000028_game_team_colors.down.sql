@@ -0,0 +1,3 @@
+ALTER TABLE games
+DROP COLUMN IF EXISTS team1_color,
+DROP COLUMN IF EXISTS team2_color;
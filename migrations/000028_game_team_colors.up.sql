@@ -0,0 +1,3 @@
+ALTER TABLE games
+ADD COLUMN team1_color VARCHAR,
+ADD COLUMN team2_color VARCHAR;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_streakmilestones_orgid;
+DROP TABLE IF EXISTS streakmilestones;
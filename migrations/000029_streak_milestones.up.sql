@@ -0,0 +1,14 @@
+CREATE TABLE streakmilestones (
+    milestoneid SERIAL PRIMARY KEY,
+    orgid INT NOT NULL,
+    userid INT NOT NULL,
+    gameid INT NOT NULL,
+    streak INT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_orgid FOREIGN KEY (orgid) REFERENCES organizations(orgid) ON DELETE CASCADE,
+    CONSTRAINT fk_userid FOREIGN KEY (userid) REFERENCES users(userid) ON DELETE CASCADE,
+    CONSTRAINT fk_gameid FOREIGN KEY (gameid) REFERENCES games(gameid) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_streakmilestones_orgid ON streakmilestones(orgid);
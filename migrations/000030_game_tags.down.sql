@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS gametagassignments;
+DROP TABLE IF EXISTS gametags;
@@ -0,0 +1,22 @@
+CREATE TABLE gametags (
+    tagid SERIAL PRIMARY KEY,
+    orgid INT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    rated BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_orgid FOREIGN KEY (orgid) REFERENCES organizations(orgid) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX unique_tag_name_per_org ON gametags(orgid, LOWER(name));
+
+CREATE TABLE gametagassignments (
+    gameid INT NOT NULL,
+    tagid INT NOT NULL,
+
+    CONSTRAINT pk_gametagassignments PRIMARY KEY (gameid, tagid),
+    CONSTRAINT fk_gameid FOREIGN KEY (gameid) REFERENCES games(gameid) ON DELETE CASCADE,
+    CONSTRAINT fk_tagid FOREIGN KEY (tagid) REFERENCES gametags(tagid) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_gametagassignments_tagid ON gametagassignments(tagid);
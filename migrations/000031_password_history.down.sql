@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_passwordhistory_userid;
+DROP TABLE IF EXISTS passwordhistory;
@@ -0,0 +1,10 @@
+CREATE TABLE passwordhistory (
+    historyid SERIAL PRIMARY KEY,
+    userid INT NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_userid FOREIGN KEY (userid) REFERENCES users(userid) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_passwordhistory_userid ON passwordhistory(userid);
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN joined_at TIMESTAMP WITH TIME ZONE;
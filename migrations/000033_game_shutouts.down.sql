@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_games_shutout;
+
+ALTER TABLE games DROP COLUMN IF EXISTS shutout;
@@ -0,0 +1,4 @@
+ALTER TABLE games
+ADD COLUMN shutout BOOLEAN GENERATED ALWAYS AS (LEAST(team1_score, team2_score) = 0) STORED;
+
+CREATE INDEX idx_games_shutout ON games(orgid) WHERE shutout;
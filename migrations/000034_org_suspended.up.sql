@@ -0,0 +1 @@
+ALTER TABLE organizations ADD COLUMN suspended_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,2 @@
+ALTER TABLE organizationsettings
+ADD COLUMN welcomemessage VARCHAR(500);
@@ -0,0 +1,7 @@
+ALTER TABLE playerratings
+DROP COLUMN last_decay_at,
+DROP COLUMN decayed;
+
+ALTER TABLE organizationsettings
+DROP COLUMN decayafterdays,
+DROP COLUMN decayrate;
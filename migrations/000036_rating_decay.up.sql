@@ -0,0 +1,7 @@
+ALTER TABLE organizationsettings
+ADD COLUMN decayrate INT DEFAULT 0,
+ADD COLUMN decayafterdays INT DEFAULT 14;
+
+ALTER TABLE playerratings
+ADD COLUMN decayed INT NOT NULL DEFAULT 0,
+ADD COLUMN last_decay_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,14 @@
+CREATE TABLE gamepresets (
+    presetid SERIAL PRIMARY KEY,
+    orgid INT NOT NULL,
+    userid INT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    team1 INT[] NOT NULL,
+    team2 INT[] NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_orgid FOREIGN KEY (orgid) REFERENCES organizations(orgid) ON DELETE CASCADE,
+    CONSTRAINT fk_userid FOREIGN KEY (userid) REFERENCES users(userid) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX unique_preset_name_per_user ON gamepresets(orgid, userid, LOWER(name));
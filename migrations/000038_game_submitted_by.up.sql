@@ -0,0 +1,12 @@
+ALTER TABLE games
+ADD COLUMN submitted_by INT;
+
+ALTER TABLE games
+ADD CONSTRAINT fk_submitted_by
+FOREIGN KEY (submitted_by)
+REFERENCES users(userid)
+ON DELETE SET NULL;
+
+UPDATE games SET submitted_by = reported_by;
+
+CREATE INDEX idx_games_submitted_by ON games(submitted_by, createdat);
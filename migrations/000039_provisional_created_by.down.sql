@@ -0,0 +1,5 @@
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS fk_users_created_by;
+
+ALTER TABLE users
+DROP COLUMN created_by;
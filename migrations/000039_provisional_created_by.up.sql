@@ -0,0 +1,8 @@
+ALTER TABLE users
+ADD COLUMN created_by INT;
+
+ALTER TABLE users
+ADD CONSTRAINT fk_users_created_by
+FOREIGN KEY (created_by)
+REFERENCES users(userid)
+ON DELETE SET NULL;
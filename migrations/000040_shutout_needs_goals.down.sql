@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_games_shutout;
+
+ALTER TABLE games DROP COLUMN IF EXISTS shutout;
+
+ALTER TABLE games
+ADD COLUMN shutout BOOLEAN GENERATED ALWAYS AS (LEAST(team1_score, team2_score) = 0) STORED;
+
+CREATE INDEX idx_games_shutout ON games(orgid) WHERE shutout;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_games_shutout;
+
+ALTER TABLE games DROP COLUMN IF EXISTS shutout;
+
+-- A 0-0 game hasn't been played yet, so it can't be a shutout.
+ALTER TABLE games
+ADD COLUMN shutout BOOLEAN GENERATED ALWAYS AS (LEAST(team1_score, team2_score) = 0 AND GREATEST(team1_score, team2_score) > 0) STORED;
+
+CREATE INDEX idx_games_shutout ON games(orgid) WHERE shutout;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_games_submitted_by_kiosk;
+
+ALTER TABLE games
+DROP CONSTRAINT IF EXISTS fk_submitted_by_kiosk;
+
+ALTER TABLE games
+DROP COLUMN submitted_by_kiosk;
@@ -0,0 +1,10 @@
+ALTER TABLE games
+ADD COLUMN submitted_by_kiosk INT;
+
+ALTER TABLE games
+ADD CONSTRAINT fk_submitted_by_kiosk
+FOREIGN KEY (submitted_by_kiosk)
+REFERENCES kiosktokens(kioskid)
+ON DELETE SET NULL;
+
+CREATE INDEX idx_games_submitted_by_kiosk ON games(submitted_by_kiosk, createdat);
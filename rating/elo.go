@@ -0,0 +1,29 @@
+package rating
+
+import "math"
+
+const (
+	DefaultRating = 1000
+	KFactor       = 32
+)
+
+func WinProbability(rating, opponent float64) float64 {
+	return 1 / (1 + math.Pow(10, (opponent-rating)/400))
+}
+
+func TeamRating(ratings []int) float64 {
+	if len(ratings) == 0 {
+		return DefaultRating
+	}
+
+	var sum int
+	for _, r := range ratings {
+		sum += r
+	}
+	return float64(sum) / float64(len(ratings))
+}
+
+// Delta is the number of points the winning side takes from the losing side.
+func Delta(winner, loser float64) int {
+	return int(math.Round(KFactor * (1 - WinProbability(winner, loser))))
+}
@@ -3,27 +3,86 @@ package routes
 import (
 	"pedersandvoll/foosballapi/handlers"
 	"pedersandvoll/foosballapi/middleware"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
 )
 
 func Routes(app *fiber.App, h *handlers.Handlers) {
 	app.Post("/register", h.RegisterUser)
 	app.Post("/login", h.LoginUser)
+	app.Get("/version", limiter.New(limiter.Config{Max: 30, Expiration: time.Minute}), h.GetVersion)
+	app.Get("/health", h.GetHealth)
 
 	api := app.Group("/api")
 	api.Use(middleware.AuthRequired(h.JWTSecret))
+	api.Use(middleware.KioskAccess(h.IsKioskTokenActive, map[string]bool{
+		"POST /api/game":       true,
+		"GET /api/org/members": true,
+	}))
 
 	api.Post("/refresh", h.RefreshToken)
+	api.Post("/change/password", h.ChangePassword)
 	api.Get("/users", h.GetUsers)
+	api.Get("/me/export", h.ExportMyData)
 
+	api.Get("/org", h.GetOrg)
+	api.Get("/orgs", h.GetMyOrgs)
 	api.Post("/org", h.CreateOrganization)
 	api.Post("/join/org", h.JoinOrg)
+	api.Post("/validate/org", limiter.New(limiter.Config{Max: 5, Expiration: time.Minute}), h.ValidateOrgSecret)
 	api.Post("/edit/org", h.EditOrgSettings)
+	api.Get("/org/config", h.GetEffectiveConfig)
+	api.Get("/org/members", h.GetOrgMembers)
+	api.Get("/org/members/active", h.GetActiveMembers)
+	api.Get("/org/member", h.GetMemberInfo)
+	api.Post("/merge/org", h.MergeOrganizations)
+	api.Post("/suspend/org", h.SuspendOrganization)
+	api.Post("/resume/org", h.ResumeOrganization)
+
+	api.Post("/kiosk/token", h.CreateKioskToken)
+	api.Post("/kiosk/revoke", h.RevokeKioskToken)
 
 	api.Post("/season", h.CreateSeason)
+	api.Post("/end/season", h.EndSeason)
 
 	api.Get("/lobbies", h.GetLobbies)
 	api.Post("/lobby", h.CreateLobby)
 	api.Post("/join/lobby", h.JoinLobby)
+	api.Post("/start/lobby", h.StartLobbyGame)
+
+	api.Get("/games", h.GetGames)
+	api.Get("/games/closest", h.GetClosestGames)
+	api.Get("/games/shutouts", h.GetShutouts)
+	api.Post("/game", h.CreateGame)
+	api.Get("/game/:gameid", h.GetGame)
+	api.Post("/claim/player", h.ClaimPlayer)
+
+	api.Get("/tags", h.GetTags)
+	api.Post("/tag", h.CreateTag)
+	api.Get("/presets", h.GetPresets)
+	api.Post("/preset", h.CreatePreset)
+
+	api.Post("/fixture", h.CreateFixture)
+	api.Get("/fixtures", h.GetFixtures)
+	api.Post("/fixture/:fixtureid/result", h.RecordFixtureResult)
+
+	api.Get("/stats/player", h.GetPlayerStats)
+	api.Get("/stats/players", h.GetBulkPlayerStats)
+	api.Get("/stats/activity", h.GetActivityHeatmap)
+	api.Get("/stats/timeofday", h.GetPerformanceByTimeOfDay)
+	api.Get("/stats/org", h.GetOrgStats)
+	api.Get("/stats/streaks", h.GetHotStreaks)
+
+	api.Get("/leaderboard", h.GetLeaderboard)
+	api.Get("/leaderboard/rank", h.GetPlayerRank)
+	api.Get("/leaderboard/image", h.GetLeaderboardImage)
+	api.Get("/winprobability", h.GetWinProbability)
+
+	api.Post("/balance/group", h.BalanceGroup)
+
+	api.Post("/availability", h.SetAvailability)
+	api.Get("/players/available", h.GetAvailablePlayers)
+	api.Post("/find/match", h.FindMatch)
 }
@@ -0,0 +1,10 @@
+package version
+
+// Set at build time, e.g.
+//
+//	go build -ldflags "-X pedersandvoll/foosballapi/version.Version=v1.2.0 -X pedersandvoll/foosballapi/version.Commit=$(git rev-parse HEAD) -X pedersandvoll/foosballapi/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)